@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
 
 	"github.com/spf13/cobra"
 
@@ -11,14 +9,28 @@ import (
 )
 
 var allCmd = &cobra.Command{
-	Use:                "all <jj-command> [args...]",
-	Short:              "Run jj command across all workspaces",
-	Long:               `Run a jj command across all repositories in a multi-workspace setup.`,
+	Use:   "all [--jobs N] [--timeout D] <jj-command> [args...]",
+	Short: "Run jj command across all workspaces",
+	Long: `Run a jj command across all repositories in a multi-workspace setup.
+
+Repos run one at a time with the terminal, so jj can prompt or open an
+editor. --jobs N runs them in parallel without a terminal and prints
+their output in config order. --timeout gives up on a slow repo, e.g.
+--timeout 5s. Both options go before the jj command.`,
 	DisableFlagParsing: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		args, err := parseRepoFlags(args)
+		if err != nil {
+			return err
+		}
 		if len(args) == 0 {
 			return fmt.Errorf("usage: jjtask all <jj-command> [args...]")
 		}
+		// Unlike find and prime, any jj command may need stdin or a TTY, so
+		// [workspaces] jobs does not apply
+		if repoJobs == 0 {
+			repoJobs = 1
+		}
 
 		repos, workspaceRoot, err := workspace.GetRepos()
 		if err != nil {
@@ -33,48 +45,25 @@ var allCmd = &cobra.Command{
 			fmt.Println()
 		}
 
-		for _, repo := range repos {
-			repoPath := workspace.ResolveRepoPath(repo, workspaceRoot)
-
+		return forEachRepo(repos, workspaceRoot, func(r repoRun) error {
 			if isMulti {
-				displayPath := workspace.RelativePath(repoPath)
-				fmt.Printf("=== %s: jj -R %s %s ===\n", workspace.DisplayName(repo), displayPath, args[0])
+				displayPath := workspace.RelativePath(r.Path)
+				_, _ = fmt.Fprintf(r.Out, "=== %s: jj -R %s %s ===\n", workspace.DisplayName(r.Repo), displayPath, args[0])
 			}
 
-			// Build jj command
-			jjArgs := []string{}
-			if globals.Color != "" {
-				jjArgs = append(jjArgs, "--color", globals.Color)
-			} else if client.IsTTY {
-				jjArgs = append(jjArgs, "--color=always")
-			}
-			jjArgs = append(jjArgs, "-R", repoPath)
-			jjArgs = append(jjArgs, args...)
-
-			jjCmd := exec.Command("jj", jjArgs...)
-			jjCmd.Stdin = os.Stdin
-			jjCmd.Stdout = os.Stdout
-			jjCmd.Stderr = os.Stderr
-			jjCmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
-
-			err := jjCmd.Run()
+			err := r.Client.Run(args...)
 			if err != nil && isMulti {
 				// In multi-repo mode, continue on error
-				if client.IsTTY {
-					fmt.Println("~  \033[32m(no output)\033[0m")
-				} else {
-					fmt.Println("~  (no output)")
-				}
+				printRepoPlaceholder(r.Out, "(no output)", err)
 			} else if err != nil {
 				return err
 			}
 
 			if isMulti {
-				fmt.Println()
+				_, _ = fmt.Fprintln(r.Out)
 			}
-		}
-
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var batchDescRevset string
@@ -29,29 +31,19 @@ Examples:
 		}
 
 		// Get matching revisions
-		out, err := client.Query("log", "-r", revset, "--no-graph", "-T", "change_id.shortest() ++ \"\\n\"")
+		revs, err := task.Load(client, revset)
 		if err != nil {
 			return fmt.Errorf("failed to query revisions: %w", err)
 		}
 
-		revs := strings.Split(strings.TrimSpace(out), "\n")
-		if len(revs) == 0 || (len(revs) == 1 && revs[0] == "") {
+		if len(revs) == 0 {
 			fmt.Println("No matching revisions")
 			return nil
 		}
 
 		changed := 0
-		for _, rev := range revs {
-			rev = strings.TrimSpace(rev)
-			if rev == "" {
-				continue
-			}
-
-			desc, err := client.GetDescription(rev)
-			if err != nil {
-				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to get description for %s: %v\n", rev, err)
-				continue
-			}
+		for _, t := range revs {
+			rev, desc := t.ChangeID, t.Description
 
 			// Run sed
 			sedCmd := exec.Command("sed", sedExpr)
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var checkUncheck bool
+
+var checkCmd = &cobra.Command{
+	Use:   "check <task> [n|text]",
+	Short: "Tick acceptance criteria checkboxes",
+	Long: `Tick a Markdown checkbox ("- [ ] item") in a task description.
+
+Select the item by its number (as listed without an item argument) or by
+text that matches exactly one item. Without an item, lists the checklist.
+'jjtask done' refuses to mark a task done while items are unchecked.
+
+Examples:
+  jjtask check xyz                 # list checklist items
+  jjtask check xyz 2               # tick item 2
+  jjtask check xyz "expire"        # tick the item mentioning "expire"
+  jjtask check xyz 2 --uncheck     # untick item 2`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := resolveTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			printChecklist(t.Checklist)
+			return nil
+		}
+
+		item, err := task.FindCheckItem(t.Checklist, args[1])
+		if err != nil {
+			return err
+		}
+		if item.Done == !checkUncheck {
+			return nil // already in the requested state
+		}
+
+		desc := task.SetChecked(t.Description, item, !checkUncheck)
+		if err := client.SetDescription(args[0], desc); err != nil {
+			return fmt.Errorf("failed to update checklist: %w", err)
+		}
+
+		done, total := task.Progress(task.ParseChecklist(desc))
+		fmt.Printf("%s %s (%d/%d)\n", checkbox(!checkUncheck), item.Text, done, total)
+		return nil
+	},
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+func printChecklist(items []task.CheckItem) {
+	for i, item := range items {
+		fmt.Printf("%2d. %s %s\n", i+1, checkbox(item.Done), item.Text)
+	}
+}
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkUncheck, "uncheck", false, "Untick the item instead")
+	checkCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeTaskRevision(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	rootCmd.AddCommand(checkCmd)
+}
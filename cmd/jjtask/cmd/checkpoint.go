@@ -30,15 +30,15 @@ Examples:
 		opID = strings.TrimSpace(opID)
 
 		if message != "" {
-			fmt.Printf("Checkpoint '%s' at operation: %s\n", message, opID)
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Checkpoint '%s' at operation: %s\n", message, opID)
 		} else {
-			fmt.Printf("Checkpoint at operation: %s\n", opID)
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Checkpoint at operation: %s\n", opID)
 		}
-		fmt.Printf("  Restore with: jj op restore %s\n", opID)
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "  Restore with: jj op restore %s\n", opID)
 
 		// Show current state
-		fmt.Println()
-		fmt.Println("  Current state:")
+		_, _ = fmt.Fprintln(cmd.OutOrStdout())
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "  Current state:")
 		if err := client.Run("log", "-r", "@", "--limit", "3"); err != nil {
 			return err
 		}
@@ -49,5 +49,6 @@ Examples:
 
 func init() {
 	checkpointCmd.Flags().StringVarP(&checkpointMessage, "message", "m", "", "checkpoint message")
+	withResultFormat(checkpointCmd)
 	rootCmd.AddCommand(checkpointCmd)
 }
@@ -127,7 +127,7 @@ func completeTaskFlag(_cmd *cobra.Command, _args []string, _toComplete string) (
 		"review\tNeeds review",
 		"done\tComplete",
 	}
-	return flags, cobra.ShellCompDirectiveNoFileComp
+	return append(flags, customStatusCompletions()...), cobra.ShellCompDirectiveNoFileComp
 }
 
 // completeFindFlag provides completion for find command flag argument
@@ -142,7 +142,21 @@ func completeFindFlag(cmd *cobra.Command, args []string, toComplete string) ([]s
 		"untested\tImplementation done, needs testing",
 		"draft\tDraft tasks",
 		"review\tTasks needing review",
+		"ready\tTodo tasks with nothing left to wait for",
 		"all\tAll tasks",
 	}
-	return flags, cobra.ShellCompDirectiveNoFileComp
+	return append(flags, customStatusCompletions()...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// customStatusCompletions lists [[statuses.custom]] names with descriptions
+func customStatusCompletions() []string {
+	var flags []string
+	for _, s := range customStatuses {
+		if s.Description != "" {
+			flags = append(flags, s.Name+"\t"+s.Description)
+		} else {
+			flags = append(flags, s.Name)
+		}
+	}
+	return flags
 }
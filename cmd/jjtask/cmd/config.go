@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
+)
+
+var (
+	configInstallDir   string
+	configInstallForce bool
+)
+
+// jjConfigMarker identifies files written by 'jjtask config generate'
+const jjConfigMarker = "# jjtask base config"
+
+// flagJJColors are the jj colors of the built-in statuses, as TOML values
+var flagJJColors = [][2]string{
+	{"done", `"green"`},
+	{"todo", `"yellow"`},
+	{"wip", `"cyan"`},
+	{"blocked", `"red"`},
+	{"standby", `{ fg = "bright black" }`},
+	{"draft", `{ fg = "bright black", italic = true }`},
+	{"untested", `"magenta"`},
+	{"review", `"blue"`},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Generate the jj config jjtask relies on",
+	Long: `Generate the revset aliases, templates and colors jjtask needs in jj's
+config, including custom statuses and [revsets] overrides from .jjtask.toml.
+
+Examples:
+  jjtask config generate > ~/.config/jj/conf.d/10-jjtask.toml
+  jjtask config install              # same, replacing an older copy`,
+}
+
+var configGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Print the jj config as TOML",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateJJConfig(cmd.OutOrStdout())
+	},
+}
+
+var configInstallCmd = &cobra.Command{
+	Use:   "install [--dir DIR]",
+	Short: "Write the jj config into jj's conf.d",
+	Long: `Write the generated config to 10-jjtask.toml in jj's user conf.d
+($XDG_CONFIG_HOME/jj/conf.d, or ~/.config/jj/conf.d).
+
+A symlink to a checkout's config (from install.sh) or an earlier generated
+file is replaced; any other file is left alone unless --force is given.
+Run it again after upgrading jjtask or changing statuses and revsets.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := configInstallDir
+		if dir == "" {
+			dir = jj.UserConfDir()
+			if dir == "" {
+				return fmt.Errorf("cannot locate jj config directory, use --dir")
+			}
+		}
+		path, err := installJJConfig(dir, configInstallForce)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed jj config → %s\n", path)
+		return nil
+	},
+}
+
+// installJJConfig writes the generated config to dir, returning its path
+func installJJConfig(dir string, force bool) (string, error) {
+	var buf bytes.Buffer
+	if err := generateJJConfig(&buf); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, jj.ConfigFileName)
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(path); err != nil {
+				return "", err
+			}
+		} else if !force {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			if !bytes.Contains(data, []byte(jjConfigMarker)) {
+				return "", fmt.Errorf("%s was not written by jjtask, use --force to replace it", path)
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// generateJJConfig writes the jj config for the current statuses and
+// [revsets] overrides
+func generateJJConfig(w io.Writer) error {
+	revsets, err := taskRevsets()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(`"$schema" = "https://jj-vcs.github.io/jj/latest/config-schema.json"` + "\n\n")
+	b.WriteString(jjConfigMarker + ", generated by 'jjtask config generate'\n")
+	b.WriteString("# Install: jjtask config install\n\n")
+
+	b.WriteString("[colors]\nhint = { fg = \"bright black\" }\n\n# Task status colors\n")
+	for _, c := range flagJJColors {
+		fmt.Fprintf(&b, "\"task %s\" = %s\n", c[0], c[1])
+	}
+	for _, s := range customStatuses {
+		if s.Color != "" {
+			fmt.Fprintf(&b, "\"task %s\" = %s\n", s.Name, strconv.Quote(s.Color))
+		}
+	}
+
+	b.WriteString("\n[revset-aliases]\n")
+	for _, r := range revsets {
+		fmt.Fprintf(&b, "%s = %s\n", strconv.Quote(r[0]), tomlString(r[1]))
+	}
+
+	b.WriteString("\n[template-aliases]\n")
+	b.WriteString("'task_flag' = '''\n" + taskFlagTemplate(validFlags) + "\n'''\n\n")
+	writeTemplateAliases(&b, jjTemplateAliases)
+
+	b.WriteString("\n[aliases]\ntask = [\"util\", \"exec\", \"--\", \"jjtask\"]\n")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// taskRevsets returns jjtask's revset aliases in the order they are written,
+// with [revsets] overrides from .jjtask.toml applied
+func taskRevsets() ([][2]string, error) {
+	scope, err := config.GetScopeConfig()
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := tasksRevset(scope)
+	if err != nil {
+		return nil, err
+	}
+	revsets := [][2]string{
+		{"tasks()", tasks},
+		{"tasks_pending()", pendingRevset()},
+	}
+	for _, flag := range validFlags {
+		revsets = append(revsets, [2]string{"tasks_" + flag + "()", flagRevset(flag)})
+	}
+	revsets = append(revsets,
+		[2]string{"tasks_ready()", "tasks_todo() ~ children(tasks_pending() ~ tasks_todo())"},
+		[2]string{"tasks_stale()", "tasks_pending() ~ (::@)"},
+		[2]string{"tasks_next()", "heads(tasks_ready() & children(::@))"},
+	)
+
+	overrides, err := config.GetRevsets()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(overrides)) {
+		i := slices.IndexFunc(revsets, func(r [2]string) bool { return r[0] == name })
+		if i >= 0 {
+			revsets[i][1] = overrides[name]
+		} else {
+			revsets = append(revsets, [2]string{name, overrides[name]})
+		}
+	}
+	return revsets, nil
+}
+
+// applyRevsets passes [revsets] overrides to jj through c
+func applyRevsets(c *jj.Client) error {
+	overrides, err := config.GetRevsets()
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(overrides)) {
+		c.Globals.Config = append(c.Globals.Config,
+			fmt.Sprintf("revset-aliases.%s=%s", strconv.Quote(name), strconv.Quote(overrides[name])))
+	}
+	return nil
+}
+
+// pendingRevset matches tasks without a done status
+func pendingRevset() string {
+	revset := "tasks()"
+	for _, flag := range task.DoneFlags {
+		revset += fmt.Sprintf(` & ~description(substring:"[task:%s]")`, flag)
+	}
+	return revset
+}
+
+// flagRevset matches tasks with flag
+func flagRevset(flag string) string {
+	return fmt.Sprintf(`tasks() & description(substring:"[task:%s]")`, flag)
+}
+
+// tomlString quotes s as a TOML literal string when possible
+func tomlString(s string) string {
+	if strings.ContainsAny(s, "'\n") {
+		return strconv.Quote(s)
+	}
+	return "'" + s + "'"
+}
+
+func init() {
+	configCmd.AddCommand(configGenerateCmd, configInstallCmd)
+	rootCmd.AddCommand(configCmd)
+
+	configInstallCmd.Flags().StringVar(&configInstallDir, "dir", "", "conf.d directory to write to (default: jj's user conf.d)")
+	configInstallCmd.Flags().BoolVar(&configInstallForce, "force", false, "Replace a file not written by jjtask")
+}
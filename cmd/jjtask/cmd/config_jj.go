@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// templateAliasGroup is a run of template aliases written together, with an
+// optional comment line above it
+type templateAliasGroup struct {
+	comment string
+	aliases [][2]string // name, template
+}
+
+// jjTemplateAliases are the template aliases written after task_flag by
+// 'jjtask config generate'
+var jjTemplateAliases = []templateAliasGroup{
+	{aliases: [][2]string{
+		{"task_title", `if(description.starts_with("[task:"),
+  description.first_line().remove_prefix("[task:" ++ task_flag ++ "] "),
+  description.first_line()
+)`},
+	}},
+	{aliases: [][2]string{
+		{"task_body_content", `description.remove_prefix(description.first_line() ++ "\n").split("\n", 6).filter(|l| !l.starts_with("#") && l.len() > 0)`},
+		{"task_body", `task_body_content.join(" ")`},
+	}},
+	{aliases: [][2]string{
+		{"task_oneline", `separate(" ",
+  if(description.starts_with("[task:"), label("task " ++ task_flag, "[task:" ++ task_flag ++ "]"), ""),
+  format_short_change_id(change_id),
+  task_title,
+) ++ "\n"`},
+	}},
+	{aliases: [][2]string{
+		{"desc_lines", `description.trim().lines().len()`},
+		{"has_spec", `desc_lines > 3 && description.starts_with("[task:")`},
+		{"desc_more_text", `"[desc:" ++ desc_lines ++ "L]"`},
+	}},
+	{comment: `Acceptance-criteria checkboxes ("- [ ] item" / "- [x] item")`, aliases: [][2]string{
+		{"task_is_check(line)", `"-*+".contains(line.trim_start().substr(0, 1)) && (task_is_open(line) || line.trim_start().substr(1, 5).starts_with(" [x]") || line.trim_start().substr(1, 5).starts_with(" [X]"))`},
+		{"task_is_open(line)", `line.trim_start().substr(1, 5).starts_with(" [ ]")`},
+		{"task_checks", `description.lines().filter(|l| task_is_check(l))`},
+		{"task_check_done", `task_checks.filter(|l| !task_is_open(l))`},
+		{"task_progress", `if(task_checks.len() > 0, " " ++ label("hint", "[" ++ task_check_done.len() ++ "/" ++ task_checks.len() ++ "]"), "")`},
+	}},
+	{comment: "Repo prefix for change IDs, set per repo by multi-repo 'jjtask find'", aliases: [][2]string{
+		{"task_repo", `""`},
+	}},
+	{aliases: [][2]string{
+		{"parent_ids", `parents.map(|p| p.change_id().shortest()).join(",")`},
+	}},
+	{aliases: [][2]string{
+		{"task_log", `if(root,
+  format_root_commit(self),
+  label(if(current_working_copy, "working_copy"),
+    concat(
+      separate(" ",
+        task_repo ++ format_short_change_id(change_id),
+        if(description.starts_with("[task:"), label("task " ++ task_flag, "[task:" ++ task_flag ++ "]"), ""),
+            task_title,
+      ),
+      task_progress,
+      if(has_spec, " " ++ label("hint", desc_more_text), ""),
+      "\n",
+      if(description.starts_with("[task:") && task_body_content.len() > 0,
+        "   " ++ truncate_end(120, task_body, "...") ++ "\n",
+        ""
+      ),
+      if(current_working_copy && !empty, diff.stat(80) ++ "\n", ""),
+    ),
+  )
+)`},
+	}},
+	{aliases: [][2]string{
+		{"task_log_flat", `if(root,
+  "",
+  label(if(current_working_copy, "working_copy"),
+    concat(
+      if(current_working_copy, "@  ", "○  "),
+      separate(" ",
+        task_repo ++ format_short_change_id(change_id),
+        "(" ++ parent_ids ++ ")",
+        if(description.starts_with("[task:"), label("task " ++ task_flag, "[task:" ++ task_flag ++ "]"), ""),
+        task_title,
+      ),
+      task_progress,
+      if(has_spec, " " ++ label("hint", desc_more_text), ""),
+      "\n",
+      if(description.starts_with("[task:") && task_body_content.len() > 0,
+        "      " ++ truncate_end(120, task_body, "...") ++ "\n",
+        ""
+      ),
+    ),
+  )
+)`},
+	}},
+	{aliases: [][2]string{
+		{"task_minimal", `format_short_change_id(change_id) ++ " " ++ label("task " ++ task_flag, "[task:" ++ task_flag ++ "]") ++ " " ++ task_title ++ "\n"`},
+	}},
+	{comment: "Clean template for test snapshots (no email, no commit hash)", aliases: [][2]string{
+		{"test_log", `if(root,
+  format_root_commit(self),
+  concat(
+    format_short_change_id(change_id),
+    " ",
+    description.first_line(),
+    "\n",
+  )
+)`},
+	}},
+}
+
+// writeTemplateAliases writes groups as TOML, with multi-line templates as
+// multi-line literal strings and a blank line between groups
+func writeTemplateAliases(b *strings.Builder, groups []templateAliasGroup) {
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if g.comment != "" {
+			b.WriteString("# " + g.comment + "\n")
+		}
+		for _, a := range g.aliases {
+			if strings.Contains(a[1], "\n") {
+				fmt.Fprintf(b, "'%s' = '''\n%s\n'''\n", a[0], a[1])
+			} else {
+				fmt.Fprintf(b, "'%s' = %s\n", a[0], tomlString(a[1]))
+			}
+		}
+	}
+}
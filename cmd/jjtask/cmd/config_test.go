@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/jj"
+)
+
+func TestGeneratedJJConfigIsCheckedIn(t *testing.T) {
+	var files []string
+	for _, path := range []string{"../../../config/conf.d/10-jjtask.toml", "../../../config/jjtask.toml"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, string(data))
+	}
+	useStatusConfig(t, "")
+
+	var out strings.Builder
+	if err := generateJJConfig(&out); err != nil {
+		t.Fatal(err)
+	}
+	for i, data := range files {
+		if data != out.String() {
+			t.Errorf("config file %d is out of date, regenerate it with 'jjtask config generate'", i)
+		}
+	}
+}
+
+func TestGenerateJJConfigOverrides(t *testing.T) {
+	useStatusConfig(t, `
+[[statuses.custom]]
+name = "qa"
+color = "blue"
+
+[revsets]
+"tasks()" = "all() & description(substring:'[task:')"
+"mine_todo()" = 'tasks_todo() & mine()'
+`)
+	c := &jj.Client{}
+	if err := applyStatuses(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRevsets(c); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := generateJJConfig(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"task qa" = "blue"`,
+		`"tasks()" = "all() & description(substring:'[task:')"`,
+		`"tasks_qa()" = 'tasks() & description(substring:"[task:qa]")'`,
+		`"mine_todo()" = 'tasks_todo() & mine()'`,
+		`if(description.starts_with("[task:qa]"), "qa",`,
+	} {
+		if !strings.Contains(out.String(), want+"\n") {
+			t.Errorf("generated config missing %s", want)
+		}
+	}
+	if !slices.Contains(c.Globals.Config, `revset-aliases."mine_todo()"="tasks_todo() & mine()"`) {
+		t.Errorf("overrides = %v, want mine_todo()", c.Globals.Config)
+	}
+}
+
+func TestInstallJJConfig(t *testing.T) {
+	useStatusConfig(t, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, jj.ConfigFileName)
+
+	// A symlink from install.sh is replaced, not written through
+	target := filepath.Join(t.TempDir(), "checkout.toml")
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installJJConfig(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "old" {
+		t.Errorf("symlink target was overwritten")
+	}
+	if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("want a regular file at %s", path)
+	}
+
+	// Reinstalling over a generated file is fine
+	if _, err := installJJConfig(dir, false); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("[colors]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installJJConfig(dir, false); err == nil || !strings.Contains(err.Error(), "use --force") {
+		t.Errorf("err = %v, want refusal for a foreign file", err)
+	}
+	if _, err := installJJConfig(dir, true); err != nil {
+		t.Errorf("force: %v", err)
+	}
+}
@@ -5,11 +5,18 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var (
-	createDraft bool
-	createChain bool
+	createDraft    bool
+	createChain    bool
+	createPriority string
+	createAssignee string
+	createLabels   []string
+	createDue      string
+	createEstimate string
 )
 
 var createCmd = &cobra.Command{
@@ -25,7 +32,8 @@ Examples:
   jjtask create xyz "Fix bug"                  # direct child of xyz
   jjtask create @ "Fix bug" "Description"      # explicit @ with description
   jjtask create --chain "Next step"            # chain from deepest pending
-  jjtask create --draft "Future work"          # draft task`,
+  jjtask create --draft "Future work"          # draft task
+  jjtask create "Add index" --priority high --label db --assignee alice`,
 	Args: cobra.RangeArgs(1, 3),
 	RunE: runCreate,
 }
@@ -33,6 +41,14 @@ Examples:
 func init() {
 	createCmd.Flags().BoolVar(&createDraft, "draft", false, "Create with [task:draft] flag")
 	createCmd.Flags().BoolVar(&createChain, "chain", false, "Auto-chain from deepest pending descendant")
+	createCmd.Flags().StringVar(&createPriority, "priority", "", "Priority trailer ("+strings.Join(task.Priorities, ", ")+")")
+	createCmd.Flags().StringVar(&createAssignee, "assignee", "", "Assignee trailer (person or agent)")
+	createCmd.Flags().StringSliceVar(&createLabels, "label", nil, "Label to add (repeatable or comma-separated)")
+	createCmd.Flags().StringVar(&createDue, "due", "", "Due date trailer (YYYY-MM-DD)")
+	createCmd.Flags().StringVar(&createEstimate, "estimate", "", "Estimate trailer (e.g. 30m, 2h, 1d)")
+	_ = createCmd.RegisterFlagCompletionFunc("priority", cobra.FixedCompletions(task.Priorities, cobra.ShellCompDirectiveNoFileComp))
+	withResultFormat(createCmd)
+	withRepoRefs(createCmd)
 	rootCmd.AddCommand(createCmd)
 	createCmd.ValidArgsFunction = completeRevision
 }
@@ -40,6 +56,11 @@ func init() {
 func runCreate(cmd *cobra.Command, args []string) error {
 	var title, desc, parent string
 
+	flag := "todo"
+	if createDraft {
+		flag = "draft"
+	}
+
 	// Parse args: [parent] <title> [description]
 	// Heuristic: if first arg looks like a revset (short alphanumeric, @, or contains revision chars),
 	// treat it as parent. Otherwise it's the title.
@@ -64,9 +85,16 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		parent = "@"
 	}
 
+	message, err := withCreateMetadata(task.Format(flag, title, desc))
+	if err != nil {
+		return err
+	}
+
 	// Check if @ is a WIP task when using explicit parent (not @)
 	if parent != "@" {
-		checkWipSuggestion(cmd)
+		if err := checkWipSuggestion(cmd); err != nil {
+			return err
+		}
 	}
 
 	// Auto-chain: find deepest pending descendant (only with --chain flag)
@@ -77,17 +105,7 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	flag := "todo"
-	if createDraft {
-		flag = "draft"
-	}
-
-	message := fmt.Sprintf("[task:%s] %s", flag, title)
-	if desc != "" {
-		message = message + "\n\n" + desc
-	}
-
-	err := client.Run("new", "--no-edit", parent, "-m", message)
+	err = client.Run("new", "--no-edit", parent, "-m", message)
 	if err != nil {
 		return err
 	}
@@ -95,19 +113,41 @@ func runCreate(cmd *cobra.Command, args []string) error {
 	// Get the created revision's change ID
 	out, err := client.Query("log", "-r", "children("+parent+") & description(substring:\"[task:\") & heads(all())", "--no-graph", "-T", "change_id.shortest()", "--limit", "1")
 	if err != nil {
-		fmt.Printf("Created task [task:%s] %s (could not resolve ID: %v)\n", flag, title, err)
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created task [task:%s] %s (could not resolve ID: %v)\n", flag, title, err)
 		return nil
 	}
 	changeID := strings.TrimSpace(out)
 	if changeID == "" {
-		fmt.Printf("Created task [task:%s] %s\n", flag, title)
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created task [task:%s] %s\n", flag, title)
 	} else {
-		fmt.Printf("Created new commit %s (empty) [task:%s] %s\n", changeID, flag, title)
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created new commit %s%s (empty) [task:%s] %s\n", repoPrefix, changeID, flag, title)
 	}
 
 	return nil
 }
 
+// withCreateMetadata appends the metadata flags as trailers to message
+func withCreateMetadata(message string) (string, error) {
+	fields := []struct{ key, value string }{
+		{task.KeyPriority, createPriority},
+		{task.KeyAssignee, createAssignee},
+		{task.KeyLabels, strings.Join(createLabels, ",")},
+		{task.KeyDue, createDue},
+		{task.KeyEstimate, createEstimate},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		value, err := task.NormalizeTrailer(f.key, f.value)
+		if err != nil {
+			return "", err
+		}
+		message = task.SetTrailer(message, f.key, value)
+	}
+	return message, nil
+}
+
 // looksLikeRevset returns true if s looks like a jj revision specifier rather than a task title
 func looksLikeRevset(s string) bool {
 	if s == "" {
@@ -146,69 +186,34 @@ func looksLikeRevset(s string) bool {
 
 // findDeepestPendingDescendant finds the deepest pending task descendant of rev
 func findDeepestPendingDescendant(rev string) string {
-	// Get all pending descendants, sorted by depth (most ancestors = deepest)
-	// We want the leaf of the chain - a task with no pending children
-	out, err := client.Query("log",
-		"-r", fmt.Sprintf("(%s | descendants(%s)) & tasks_pending()", rev, rev),
-		"--no-graph",
-		"-T", `change_id.shortest() ++ "\n"`,
-	)
+	g, err := taskGraph()
 	if err != nil {
 		return ""
 	}
-
-	candidates := strings.Split(strings.TrimSpace(out), "\n")
-	if len(candidates) == 0 || (len(candidates) == 1 && candidates[0] == "") {
+	t, err := g.Resolve(rev)
+	if err != nil {
 		return ""
 	}
-
-	// Find the leaf - a candidate with no pending children
-	for _, candidate := range candidates {
-		candidate = strings.TrimSpace(candidate)
-		if candidate == "" {
-			continue
-		}
-
-		// Check if this candidate has any pending children
-		children, err := client.Query("log",
-			"-r", fmt.Sprintf("children(%s) & tasks_pending()", candidate),
-			"--no-graph",
-			"-T", "change_id.shortest()",
-			"--limit", "1",
-		)
-		if err != nil {
-			continue
-		}
-
-		if strings.TrimSpace(children) == "" {
-			// No pending children - this is the leaf
-			return candidate
-		}
-	}
-
-	// No leaf found, return last candidate
-	return strings.TrimSpace(candidates[len(candidates)-1])
+	return g.DeepestPendingDescendant(t.ChangeID)
 }
 
 // checkWipSuggestion suggests chaining to @ if @ is a WIP task
-func checkWipSuggestion(cmd *cobra.Command) {
-	atDesc, err := client.GetDescription("@")
+func checkWipSuggestion(cmd *cobra.Command) error {
+	g, err := taskGraph()
 	if err != nil {
-		return
-	}
-	if !strings.HasPrefix(atDesc, "[task:wip]") {
-		return
+		return nil
 	}
-
-	atID, err := client.Query("log", "-r", "@", "--no-graph", "-T", "change_id.shortest()")
-	if err != nil {
-		return
+	at := g.WorkingCopy()
+	if at == nil || at.Flag != "wip" {
+		return nil
 	}
-	atID = strings.TrimSpace(atID)
 
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintf(stderr, "Note: Current revision (%s) is a WIP task.\n", atID)
-	_, _ = fmt.Fprintln(stderr, "Consider: `jjtask create \"title\"` to auto-chain from @")
-	_, _ = fmt.Fprintln(stderr)
+	return warn(cmd, &Warning{
+		Code:        WarnWipParent,
+		Severity:    SeverityInfo,
+		Message:     fmt.Sprintf("Current revision (%s) is a WIP task.", at.ChangeID),
+		Revisions:   []string{at.ChangeID},
+		Suggestions: []string{`jjtask create "title"`},
+		body:        []string{"Consider: `jjtask create \"title\"` to auto-chain from @"},
+	})
 }
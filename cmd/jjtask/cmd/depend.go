@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
+	"jjtask/internal/workspace"
+)
+
+// dependencyIDLength is how much of the full change ID Depends-On records,
+// matching jj's default short change ID display
+const dependencyIDLength = 12
+
+var dependCmd = &cobra.Command{
+	Use:   "depend",
+	Short: "Manage explicit task dependencies",
+	Long: `Declare that a task depends on other tasks outside its ancestry.
+
+Dependencies are stored as "Depends-On: <change-id>" trailers in the task
+description. A todo task is only ready once all of its dependencies are
+done, and 'jjtask flag wip' warns about unfinished dependencies.
+Links that would create a cycle (including depending on a descendant)
+are rejected.
+
+In a multi-repo workspace a dependency can be a task in another repo,
+written "repo:change-id". It is looked up in that repo when checking
+readiness, and multi-repo 'jjtask find' lists these links.
+
+Examples:
+  jjtask depend add xyz abc def    # xyz depends on abc and def
+  jjtask depend add xyz api:qrs    # xyz depends on qrs in the api repo
+  jjtask depend rm xyz abc
+  jjtask depend ls xyz`,
+}
+
+var dependAddCmd = &cobra.Command{
+	Use:   "add <task> <dependency>...",
+	Short: "Add dependencies to a task",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		t, err := resolveTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		desc := t.Description
+		for _, rev := range args[1:] {
+			dep, err := resolveDependency(rev)
+			if err != nil {
+				return fmt.Errorf("resolving dependency %s: %w", rev, err)
+			}
+			// Cycles through another repo are not detected
+			if dep.Repo == "" {
+				if cycle := g.DependencyCycle(t.ChangeID, dep.ChangeID); cycle != nil {
+					return fmt.Errorf("%s cannot depend on %s: cycle %s", t.ChangeID, dep.ChangeID, strings.Join(cycle, " → "))
+				}
+			}
+			if hasDependency(g, t, dep) {
+				continue
+			}
+			desc = task.AddTrailer(desc, task.KeyDependsOn, dependencyRef(dep))
+		}
+
+		if desc == t.Description {
+			return nil
+		}
+		return client.SetDescription(args[0], desc)
+	},
+}
+
+var dependRmCmd = &cobra.Command{
+	Use:   "rm <task> <dependency>...",
+	Short: "Remove dependencies from a task",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		t, err := resolveTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		desc := t.Description
+		for _, rev := range args[1:] {
+			// Match trailers by the task they point to, falling back to the
+			// literal reference for dependencies outside the graph
+			var refs []string
+			dep, _ := resolveDependency(rev)
+			for _, ref := range t.DependsOn {
+				if ref == rev || (dep != nil && sameTask(g.LookupDependency(ref), dep)) {
+					refs = append(refs, ref)
+				}
+			}
+			if len(refs) == 0 {
+				return fmt.Errorf("%s does not depend on %s", t.ChangeID, rev)
+			}
+			desc = task.UnsetTrailer(desc, task.KeyDependsOn, refs...)
+		}
+		return client.SetDescription(args[0], desc)
+	},
+}
+
+var dependLsCmd = &cobra.Command{
+	Use:   "ls [task]",
+	Short: "List a task's dependencies and dependents",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rev := "@"
+		if len(args) > 0 {
+			rev = args[0]
+		}
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		t, err := resolveTask(rev)
+		if err != nil {
+			return err
+		}
+
+		if len(t.DependsOn) > 0 {
+			fmt.Println("Depends on:")
+			for _, ref := range t.DependsOn {
+				if dep := g.LookupDependency(ref); dep != nil {
+					fmt.Printf("  %s %s\n", dep.Ref(), dep.FirstLine())
+				} else {
+					fmt.Printf("  %s (not found)\n", ref)
+				}
+			}
+		}
+		if dependents := g.Dependents(t.ChangeID); len(dependents) > 0 {
+			fmt.Println("Required by:")
+			for _, d := range dependents {
+				fmt.Printf("  %s %s\n", d.ChangeID, d.FirstLine())
+			}
+		}
+		return nil
+	},
+}
+
+// dependencyRef is the value stored in a Depends-On trailer for dep,
+// qualified with the repo for a task in another repo
+func dependencyRef(dep *task.Task) string {
+	id := dep.ID
+	if len(id) > dependencyIDLength {
+		id = id[:dependencyIDLength]
+	}
+	if dep.Repo != "" {
+		return dep.Repo + ":" + id
+	}
+	return id
+}
+
+// hasDependency reports whether t already declares a dependency on dep
+func hasDependency(g *task.Graph, t, dep *task.Task) bool {
+	return slices.ContainsFunc(g.Dependencies(t.ChangeID), func(d *task.Task) bool { return sameTask(d, dep) })
+}
+
+// sameTask reports whether a and b are the same revision of the same repo
+func sameTask(a, b *task.Task) bool {
+	return a != nil && b != nil && a.Repo == b.Repo && a.ChangeID == b.ChangeID
+}
+
+// resolveDependency resolves a dependency to add or remove, which may be a
+// repo-qualified revision in another repo
+func resolveDependency(rev string) (*task.Task, error) {
+	dep, ok, err := getQualifiedTask(client, rev)
+	if !ok {
+		return resolveTask(rev)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !dep.IsTask() {
+		return nil, fmt.Errorf("%s is not a task", dep.Ref())
+	}
+	return dep, nil
+}
+
+// getQualifiedTask loads a repo-qualified revision like "api:xqq" from its
+// repo, with Repo set. ok is false when ref does not name a workspace repo.
+func getQualifiedTask(c *jj.Client, ref string) (t *task.Task, ok bool, err error) {
+	repos, workspaceRoot, err := workspace.GetRepos()
+	if err != nil || len(repos) < 2 {
+		return nil, false, err
+	}
+	repo, rev, ok := workspace.SplitRef(ref, repos)
+	if !ok {
+		return nil, false, nil
+	}
+	t, err = task.Get(c.ForRepo(workspace.ResolveRepoPath(repo, workspaceRoot)), rev)
+	if err != nil {
+		return nil, true, err
+	}
+	t.Repo = workspace.DisplayName(repo)
+	return t, true, nil
+}
+
+// loadGraph loads c's task graph, resolving Depends-On references to other
+// repos in the workspace
+func loadGraph(c *jj.Client) (*task.Graph, error) {
+	g, err := task.LoadGraph(c)
+	if err != nil {
+		return nil, err
+	}
+	g.SetCrossRepo(crossRepoLookup(c))
+	return g, nil
+}
+
+// crossRepoLookup resolves repo-qualified references from c's repo,
+// querying each one once
+func crossRepoLookup(c *jj.Client) func(ref string) *task.Task {
+	cache := make(map[string]*task.Task)
+	return func(ref string) *task.Task {
+		if t, ok := cache[ref]; ok {
+			return t
+		}
+		t, _, err := getQualifiedTask(c, ref)
+		if err != nil {
+			t = nil
+		}
+		cache[ref] = t
+		return t
+	}
+}
+
+// checkUnmetDependencies warns if the task declares dependencies that are not done
+func checkUnmetDependencies(cmd *cobra.Command, taskRev string) error {
+	g, err := taskGraph()
+	if err != nil {
+		return nil
+	}
+	t, err := g.Resolve(taskRev)
+	if err != nil {
+		return nil
+	}
+	unmet := g.UnmetDependencies(t.ChangeID)
+	if len(unmet) == 0 {
+		return nil
+	}
+
+	return warn(cmd, &Warning{
+		Code:      WarnUnmetDependencies,
+		Severity:  SeverityWarning,
+		Message:   "Task depends on unfinished tasks:",
+		Revisions: task.IDs(unmet),
+		body:      append(taskBullets(unmet), "Consider finishing the dependencies first."),
+	})
+}
+
+func init() {
+	for _, c := range []*cobra.Command{dependAddCmd, dependRmCmd, dependLsCmd} {
+		c.ValidArgsFunction = completeTaskRevision
+		dependCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(dependCmd)
+}
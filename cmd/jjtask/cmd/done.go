@@ -2,10 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var (
+	doneForce    bool
+	doneNoVerify bool
 )
 
 var doneCmd = &cobra.Command{
@@ -15,11 +22,22 @@ var doneCmd = &cobra.Command{
 
 If the task is a merge parent, other parents are rebased onto the done task,
 making it part of the linear history rather than a floating branch.
+If any rebase fails, the repo is restored to the operation before the
+task was marked done.
+
+Tasks with unchecked acceptance criteria ("- [ ] item") are refused
+unless --force is given.
+
+Verification commands from Verify trailers and the [done] verify list in
+.jjtask.toml run in the repo root first. If one fails, its output is shown
+and the task is flagged untested (or [done] on_fail) instead.
 
 Examples:
   jjtask done xyz       # Mark xyz as done
   jjtask done           # Mark @ as done (if it's a task)
-  jjtask done a b c     # Mark multiple tasks done`,
+  jjtask done a b c     # Mark multiple tasks done
+  jjtask done xyz -f    # Mark done despite unchecked items
+  jjtask done --no-verify xyz  # Skip verification commands`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		revs := args
@@ -27,46 +45,125 @@ Examples:
 			revs = []string{"@"}
 		}
 
+		if err := checkDone(cmd, revs); err != nil {
+			return err
+		}
+
 		var orphans []string
 		for _, rev := range revs {
-			isOrphan, err := markDone(cmd, rev)
+			changeID, isOrphan, err := markDone(cmd, rev)
 			if err != nil {
 				return fmt.Errorf("failed to mark %s done: %w", rev, err)
 			}
 			if isOrphan {
-				changeID, _ := client.Query("log", "-r", rev, "--no-graph", "-T", "change_id.shortest()")
-				orphans = append(orphans, strings.TrimSpace(changeID))
+				orphans = append(orphans, changeID)
 			}
 		}
 
 		if len(orphans) > 0 {
-			printOrphanWarning(orphans)
+			printOrphanWarning(cmd.ErrOrStderr(), orphans)
 		}
 
 		return nil
 	},
 }
 
+// checkDone runs the checks that apply to the whole batch of tasks being
+// marked done: unchecked checklists and pending children outside the batch
+func checkDone(cmd *cobra.Command, revs []string) error {
+	if err := checkChecklists(cmd, revs); err != nil {
+		return err
+	}
+	g, err := taskGraph()
+	if err != nil {
+		return fmt.Errorf("loading tasks: %w", err)
+	}
+	var batch []string
+	for _, rev := range revs {
+		if t, err := g.Resolve(rev); err == nil {
+			batch = append(batch, t.ChangeID)
+		}
+	}
+	for _, id := range batch {
+		if err := checkPendingChildren(cmd, id, batch...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChecklists refuses to mark tasks done while checklist items are
+// unchecked. With --force it only warns.
+func checkChecklists(cmd *cobra.Command, revs []string) error {
+	g, err := taskGraph()
+	if err != nil {
+		return fmt.Errorf("loading tasks: %w", err)
+	}
+
+	var warnings []*Warning
+	for _, rev := range revs {
+		t, err := g.Resolve(rev)
+		if err != nil {
+			continue // reported by markDone
+		}
+		open := task.Unchecked(t.Checklist)
+		if len(open) == 0 {
+			continue
+		}
+		_, total := task.Progress(t.Checklist)
+		w := &Warning{
+			Code:        WarnUncheckedChecklist,
+			Severity:    SeverityWarning,
+			Message:     fmt.Sprintf("%s has %d of %d checklist items unchecked:", t.ChangeID, len(open), total),
+			Revisions:   []string{t.ChangeID},
+			Suggestions: []string{"jjtask check " + t.ChangeID},
+			inline:      true,
+		}
+		for _, item := range open {
+			w.body = append(w.body, "  - [ ] "+item.Text)
+		}
+		warnings = append(warnings, w)
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if !doneForce {
+		var b strings.Builder
+		for _, w := range warnings {
+			b.WriteString(w.Message + "\n")
+			for _, line := range w.body {
+				b.WriteString(line + "\n")
+			}
+		}
+		return fmt.Errorf("%sTick items with 'jjtask check' or use --force", b.String())
+	}
+	return warn(cmd, warnings...)
+}
+
 // markDone marks a task as done and linearizes if it's a merge parent.
-// Returns true if the task is an orphan (not in @'s ancestry after marking done).
-func markDone(cmd *cobra.Command, rev string) (isOrphan bool, err error) {
-	// Get change ID
-	changeID, err := client.Query("log", "-r", rev, "--no-graph", "-T", "change_id.shortest()")
+// Returns the task's change ID and whether it is an orphan (not in @'s ancestry after marking done).
+func markDone(cmd *cobra.Command, rev string) (changeID string, isOrphan bool, err error) {
+	g, err := taskGraph()
 	if err != nil {
-		return false, fmt.Errorf("getting change ID: %w", err)
+		return "", false, fmt.Errorf("loading tasks: %w", err)
 	}
-	changeID = strings.TrimSpace(changeID)
+	t, err := g.Resolve(rev)
+	if err != nil {
+		return "", false, fmt.Errorf("getting change ID: %w", err)
+	}
+	changeID = t.ChangeID
 
 	// Check if task is a parent of @
-	parents, err := client.GetParents("@")
-	if err != nil {
-		return false, fmt.Errorf("getting @ parents: %w", err)
+	at := g.WorkingCopy()
+	if at == nil {
+		return changeID, false, fmt.Errorf("getting @ parents: working copy not found")
 	}
 
 	isParent := false
-	var otherParents []string
-	for _, p := range parents {
-		if p == changeID {
+	var otherParents []*task.Task
+	for _, p := range g.Parents(at.ChangeID) {
+		if p.ChangeID == changeID {
 			isParent = true
 		} else {
 			otherParents = append(otherParents, p)
@@ -74,28 +171,49 @@ func markDone(cmd *cobra.Command, rev string) (isOrphan bool, err error) {
 	}
 
 	// Warn about empty task or uncommitted work before marking done
-	checkEmptyTask(cmd, changeID)
-	checkWorkingCopyDiff(cmd, changeID, "done")
+	if err := checkEmptyTask(cmd, changeID); err != nil {
+		return changeID, false, err
+	}
+	if err := checkWorkingCopyDiff(cmd, changeID, "done"); err != nil {
+		return changeID, false, err
+	}
 
-	// Mark as done
-	if err := setTaskFlag(rev, "done"); err != nil {
-		return false, fmt.Errorf("setting flag: %w", err)
+	// Run verification commands; a failure flags the task instead
+	if err := verifyTask(cmd, t, g.IsAncestorOf(changeID, at.ChangeID)); err != nil {
+		return changeID, false, err
 	}
 
-	// If task is a merge parent, linearize: rebase other parents onto done task
-	if isParent && len(otherParents) > 0 {
-		if err := linearizeDoneTask(changeID, otherParents); err != nil {
-			return false, fmt.Errorf("linearizing: %w", err)
+	// Flag and linearize atomically: a failed rebase also reverts the flag
+	err = client.Transaction(func() error {
+		if err := client.SetDescription(t.ID, task.SetFlag(t.Description, "done")); err != nil {
+			return fmt.Errorf("setting flag: %w", err)
 		}
-	}
 
-	// Check if task ended up in @'s ancestry
-	inAncestry, err := client.IsAncestorOf(changeID, "@")
+		// If task is a merge parent, linearize: rebase other parents onto done task
+		if isParent && len(otherParents) > 0 {
+			if err := linearizeDoneTask(changeID, otherParents); err != nil {
+				return fmt.Errorf("linearizing: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return false, nil // Ignore error, just skip orphan check
+		return changeID, false, err
+	}
+
+	// Check if task ended up in @'s ancestry (unknowable without running the plan)
+	if client.DryRun {
+		return changeID, false, nil
+	}
+	if err := g.Refresh(); err != nil {
+		return changeID, false, nil // Ignore error, just skip orphan check
+	}
+	at = g.WorkingCopy()
+	if at == nil {
+		return changeID, false, nil
 	}
 
-	return !inAncestry, nil
+	return changeID, !g.IsAncestorOf(changeID, at.ChangeID), nil
 }
 
 // linearizeDoneTask integrates the done task into @'s linear ancestry.
@@ -109,21 +227,27 @@ func markDone(cmd *cobra.Command, rev string) (isOrphan bool, err error) {
 // 4. Rebase @ onto the task chain tip
 //
 // Result: work1 → work2 → work3 → taskA → taskB → @
-func linearizeDoneTask(doneTask string, otherParents []string) error {
+func linearizeDoneTask(doneTask string, otherParents []*task.Task) error {
+	return client.Transaction(func() error {
+		return linearize(doneTask, otherParents)
+	})
+}
+
+func linearize(doneTask string, otherParents []*task.Task) error {
 	// Separate parents into tasks and work commits
 	var taskParents, workParents []string
 	for _, parent := range otherParents {
-		if isTaskCommit(parent) {
-			taskParents = append(taskParents, parent)
+		if parent.IsTask() {
+			taskParents = append(taskParents, parent.ChangeID)
 		} else {
-			workParents = append(workParents, parent)
+			workParents = append(workParents, parent.ChangeID)
 		}
 	}
 
 	// If no work parents, use old behavior: chain everything onto done task
 	if len(workParents) == 0 {
 		base := doneTask
-		for _, parent := range otherParents {
+		for _, parent := range taskParents {
 			if err := client.Run("rebase", "-s", parent, "-o", base); err != nil {
 				return fmt.Errorf("rebasing %s onto %s: %w", parent, base, err)
 			}
@@ -163,15 +287,6 @@ func linearizeDoneTask(doneTask string, otherParents []string) error {
 	return nil
 }
 
-// isTaskCommit checks if a revision is a task commit (has [task:*] in description)
-func isTaskCommit(rev string) bool {
-	desc, err := client.Query("log", "-r", rev, "--no-graph", "-T", "description")
-	if err != nil {
-		return false
-	}
-	return strings.Contains(desc, "[task:")
-}
-
 // findWorkTip finds the newest commit among work parents
 func findWorkTip(workParents []string) (string, error) {
 	if len(workParents) == 1 {
@@ -179,34 +294,37 @@ func findWorkTip(workParents []string) (string, error) {
 	}
 
 	// Find the tip (head) of work branches
-	revset := strings.Join(workParents, " | ")
-	out, err := client.Query("log", "-r", "heads("+revset+")", "--no-graph", "-T", "change_id.shortest()", "--limit", "1")
+	g, err := taskGraph()
 	if err != nil {
 		return workParents[0], nil
 	}
-	tip := strings.TrimSpace(out)
-	if tip == "" {
+	heads := g.Heads(workParents)
+	if len(heads) == 0 {
 		return workParents[0], nil
 	}
-	return tip, nil
+	return heads[0].ChangeID, nil
 }
 
-func printOrphanWarning(orphans []string) {
+func printOrphanWarning(w io.Writer, orphans []string) {
 	revList := strings.Join(orphans, " ")
 	revUnion := strings.Join(orphans, " | ")
 
-	_, _ = fmt.Fprintf(os.Stderr, "\nWarning: %s marked done but not in @'s ancestry (orphan tasks)\n", revList)
-	_, _ = fmt.Fprintln(os.Stderr, "These tasks were never 'wip' - their specs won't be in linear history.")
-	_, _ = fmt.Fprintln(os.Stderr)
-	_, _ = fmt.Fprintln(os.Stderr, "Options:")
-	_, _ = fmt.Fprintln(os.Stderr, "  1. Consolidate specs into @ description, then abandon tasks")
-	_, _ = fmt.Fprintln(os.Stderr, "  2. Linearize into ancestry (may conflict)")
-	_, _ = fmt.Fprintln(os.Stderr, "  3. Leave as-is (manual cleanup later)")
-	_, _ = fmt.Fprintln(os.Stderr)
-	_, _ = fmt.Fprintf(os.Stderr, "View specs: jj log -r '%s' --no-graph -T description\n", revUnion)
-	_, _ = fmt.Fprintln(os.Stderr)
+	_, _ = fmt.Fprintf(w, "\nWarning: %s marked done but not in @'s ancestry (orphan tasks)\n", revList)
+	_, _ = fmt.Fprintln(w, "These tasks were never 'wip' - their specs won't be in linear history.")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Options:")
+	_, _ = fmt.Fprintln(w, "  1. Consolidate specs into @ description, then abandon tasks")
+	_, _ = fmt.Fprintln(w, "  2. Linearize into ancestry (may conflict)")
+	_, _ = fmt.Fprintln(w, "  3. Leave as-is (manual cleanup later)")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "View specs: jj log -r '%s' --no-graph -T description\n", revUnion)
+	_, _ = fmt.Fprintln(w)
 }
 
 func init() {
+	doneCmd.Flags().BoolVarP(&doneForce, "force", "f", false, "Mark done even with unchecked checklist items")
+	doneCmd.Flags().BoolVar(&doneNoVerify, "no-verify", false, "Skip Verify trailers and [done] verify commands")
+	withResultFormat(doneCmd)
+	withRepoRefs(doneCmd)
 	rootCmd.AddCommand(doneCmd)
 }
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var dropAbandon bool
@@ -24,7 +25,7 @@ Examples:
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		for _, rev := range args {
-			if err := dropTask(rev); err != nil {
+			if err := dropTask(cmd, rev); err != nil {
 				return fmt.Errorf("failed to drop %s: %w", rev, err)
 			}
 		}
@@ -32,35 +33,41 @@ Examples:
 	},
 }
 
-func dropTask(rev string) error {
+func dropTask(cmd *cobra.Command, rev string) error {
 	// Get change ID
-	changeID, err := client.Query("log", "-r", rev, "--no-graph", "-T", "change_id.shortest()")
+	g, err := taskGraph()
+	if err != nil {
+		return fmt.Errorf("loading tasks: %w", err)
+	}
+	t, err := g.Resolve(rev)
 	if err != nil {
 		return fmt.Errorf("getting change ID: %w", err)
 	}
-	changeID = strings.TrimSpace(changeID)
 
-	if dropAbandon {
-		if err := client.Run("abandon", rev); err != nil {
-			return fmt.Errorf("abandoning: %w", err)
-		}
-		fmt.Printf("Abandoned %s\n", rev)
-	} else {
-		if err := setTaskFlag(rev, "standby"); err != nil {
-			return fmt.Errorf("setting flag: %w", err)
+	return client.Transaction(func() error {
+		if dropAbandon {
+			if err := client.Run("abandon", t.ID); err != nil {
+				return fmt.Errorf("abandoning: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Abandoned %s\n", rev)
+		} else {
+			if err := client.SetDescription(t.ID, task.SetFlag(t.Description, "standby")); err != nil {
+				return fmt.Errorf("setting flag: %w", err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Marked %s as standby\n", rev)
 		}
-		fmt.Printf("Marked %s as standby\n", rev)
-	}
 
-	// Remove from @ merge (preserves @ content)
-	if err := client.RemoveFromMerge(changeID); err != nil {
-		return fmt.Errorf("removing from merge: %w", err)
-	}
+		// Remove from @ merge (preserves @ content)
+		if err := client.RemoveFromMerge(t.ChangeID); err != nil {
+			return fmt.Errorf("removing from merge: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func init() {
+	withResultFormat(dropCmd)
 	rootCmd.AddCommand(dropCmd)
 	dropCmd.Flags().BoolVar(&dropAbandon, "abandon", false, "Abandon the tasks entirely")
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/jj/jjtest"
+)
+
+func TestFilterRevsetByMetadata(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("log").Returns(logLines(t,
+		"at base: work",
+		"b base: [task:todo] B\n\nAssignee: bob\nLabels: api\n",
+		"a base: [task:todo] A\n\nAssignee: alice\nLabels: db,api\nPriority: high\n",
+	))
+
+	findAssignee, findLabels, findPriority = "", []string{"api"}, "HIGH"
+	t.Cleanup(func() { findAssignee, findLabels, findPriority = "", nil, "" })
+
+	filter, err := findMetaFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := filterRevset(fake.Client(), "tasks_pending() | @", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(tasks_pending() | @) & (at | a)"; got != want {
+		t.Errorf("filterRevset = %q, want %q", got, want)
+	}
+}
+
+func TestCreateMetadataTrailers(t *testing.T) {
+	createPriority, createLabels, createAssignee = "High", []string{"db", "api"}, "alice"
+	t.Cleanup(func() { createPriority, createLabels, createAssignee = "", nil, "" })
+
+	got, err := withCreateMetadata("[task:todo] Add index\n\nSpec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[task:todo] Add index\n\nSpec\n\nPriority: high\nAssignee: alice\nLabels: db,api\n"
+	if got != want {
+		t.Errorf("withCreateMetadata = %q, want %q", got, want)
+	}
+
+	createPriority = "urgent"
+	if _, err := withCreateMetadata("[task:todo] X"); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+}
+
+func TestDoneRefusesUncheckedChecklist(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t,
+		"at a: work",
+		"a base: [task:wip] A\n\n- [x] migrate\n- [ ] backfill\n",
+	))
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	err := checkChecklists(cmd, []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "- [ ] backfill") {
+		t.Fatalf("checkChecklists = %v, want refusal listing backfill", err)
+	}
+
+	doneForce = true
+	t.Cleanup(func() { doneForce = false })
+	if err := checkChecklists(cmd, []string{"a"}); err != nil {
+		t.Fatalf("checkChecklists with --force = %v", err)
+	}
+	if !strings.Contains(stderr.String(), "1 of 2 checklist items unchecked") {
+		t.Errorf("stderr = %q, want warning", stderr.String())
+	}
+}
@@ -2,10 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var finalizeRevset string
@@ -32,30 +33,21 @@ Examples:
 		}
 
 		// Get revisions matching the revset
-		revsOut, err := client.Query("log", "-r", revset, "--no-graph", "-T", `change_id.shortest() ++ "\n"`)
+		revs, err := task.Load(client, revset)
 		if err != nil {
 			return fmt.Errorf("failed to get revisions: %w", err)
 		}
 
-		taskPrefixRe := regexp.MustCompile(`^\[task:\w+\]\s*`)
 		count := 0
 
-		for _, rev := range strings.Split(strings.TrimSpace(revsOut), "\n") {
-			if rev == "" {
-				continue
-			}
-
-			desc, err := client.GetDescription(rev)
-			if err != nil || desc == "" {
-				continue
-			}
-
-			if !taskPrefixRe.MatchString(desc) {
+		for _, t := range revs {
+			if !t.IsTask() {
 				continue
 			}
 
-			newDesc := taskPrefixRe.ReplaceAllString(desc, "")
-			if newDesc == desc {
+			rev := t.ChangeID
+			newDesc := task.StripFlag(t.Description)
+			if newDesc == t.Description {
 				continue
 			}
 
@@ -68,13 +60,13 @@ Examples:
 			if len(firstLine) > 60 {
 				firstLine = firstLine[:57] + "..."
 			}
-			fmt.Printf("%s: %s\n", rev, firstLine)
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", rev, firstLine)
 		}
 
 		if count == 0 {
-			fmt.Println("No task prefixes to strip")
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No task prefixes to strip")
 		} else {
-			fmt.Printf("Finalized %d commit(s)\n", count)
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Finalized %d commit(s)\n", count)
 		}
 
 		return nil
@@ -82,6 +74,7 @@ Examples:
 }
 
 func init() {
+	withResultFormat(finalizeCmd)
 	rootCmd.AddCommand(finalizeCmd)
 	finalizeCmd.Flags().StringVarP(&finalizeRevset, "revset", "r", "", "Revset to finalize (for multiple commits)")
 	_ = finalizeCmd.RegisterFlagCompletionFunc("revset", completeRevision)
@@ -3,33 +3,76 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
 	"jjtask/internal/workspace"
 )
 
 var (
-	findFormat string
-	findStatus string
+	findFormat   string
+	findStatus   string
+	findLabels   []string
+	findAssignee string
+	findPriority string
+	findScope    string
+	findMerged   bool
 )
 
 type TaskItem struct {
-	ChangeID    string `json:"change_id"`
-	Flag        string `json:"flag"`
-	Title       string `json:"title"`
-	Empty       bool   `json:"empty"`
-	WorkingCopy bool   `json:"working_copy"`
-	Repo        string `json:"repo,omitempty"`
+	ChangeID    string             `json:"change_id"`
+	Flag        string             `json:"flag"`
+	Title       string             `json:"title"`
+	Empty       bool               `json:"empty"`
+	WorkingCopy bool               `json:"working_copy"`
+	Priority    string             `json:"priority,omitempty"`
+	Assignee    string             `json:"assignee,omitempty"`
+	Labels      []string           `json:"labels,omitempty"`
+	Due         string             `json:"due,omitempty"`
+	Estimate    string             `json:"estimate,omitempty"`
+	DependsOn   []string           `json:"depends_on,omitempty"`
+	BlockedBy   []string           `json:"blocked_by,omitempty"` // unfinished Depends-On tasks, repo-qualified from other repos
+	Checklist   *ChecklistProgress `json:"checklist,omitempty"`
+	Repo        string             `json:"repo,omitempty"`
+}
+
+// ChecklistProgress counts acceptance-criteria checkboxes in a task
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// newTaskItem converts a task to its JSON representation
+func newTaskItem(t *task.Task) TaskItem {
+	item := TaskItem{
+		ChangeID:    t.ChangeID,
+		Flag:        t.Flag,
+		Title:       t.Title,
+		Empty:       t.Empty,
+		WorkingCopy: t.WorkingCopy,
+		Priority:    t.Priority,
+		Assignee:    t.Assignee,
+		Labels:      t.Labels,
+		Due:         t.Due,
+		Estimate:    t.Estimate,
+		DependsOn:   t.DependsOn,
+	}
+	if done, total := task.Progress(t.Checklist); total > 0 {
+		item.Checklist = &ChecklistProgress{Done: done, Total: total}
+	}
+	return item
 }
 
 type FindOutput struct {
-	Tasks []TaskItem `json:"tasks"`
-	Count int        `json:"count"`
+	Tasks   []TaskItem   `json:"tasks"`
+	Count   int          `json:"count"`
+	Summary *FindSummary `json:"summary,omitempty"` // set by --merged
 }
 
 var findRevset string
@@ -42,7 +85,19 @@ var findCmd = &cobra.Command{
 Without arguments, shows pending tasks. With --status, shows tasks
 matching that status. Use --revset for custom filtering.
 
-Status options: pending, todo, wip, done, blocked, standby, untested, draft, review, all
+Status options: pending, todo, wip, done, blocked, standby, untested, draft, review, ready, all,
+plus custom statuses from .jjtask.toml
+
+"ready" lists todo tasks whose parents are not in progress and whose
+Depends-On dependencies are all done.
+
+Tasks are searched among descendants of the 200 nearest ancestors of @,
+or the [scope] from .jjtask.toml. --scope overrides it for one run: "all"
+for every visible revision, a number of ancestors, or a base revset.
+
+--merged replaces the per-repo graphs with one list of tasks from every
+repo, most active status first, each with its repo-qualified ID, followed
+by task counts per repo and per status.
 
 Examples:
   jjtask find                        # pending tasks (default)
@@ -50,9 +105,22 @@ Examples:
   jjtask find -s wip                 # work in progress
   jjtask find -s done                # completed tasks
   jjtask find -s all                 # all tasks including done
-  jjtask find --revset 'tasks() & mine()'`,
+  jjtask find -s ready               # tasks that can be started now
+  jjtask find --revset 'tasks() & mine()'
+  jjtask find --assignee alice       # tasks assigned to alice
+  jjtask find --label db --priority high
+  jjtask find --scope all            # include tasks far from @
+  jjtask find --merged               # one list across all repos`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(findFormat); err != nil {
+			return err
+		}
+		if findScope != "" {
+			if err := setScope(client, parseScope(findScope)); err != nil {
+				return err
+			}
+		}
 		var revset string
 		customRevset := findRevset != ""
 
@@ -60,36 +128,12 @@ Examples:
 			// Custom revset via -r flag - intersect with tasks() to only show task revisions
 			revset = fmt.Sprintf("(%s) & tasks()", findRevset)
 		} else {
-			taskRevset := "tasks_pending()"
-			status := findStatus
-			if status != "" {
-				switch status {
-				case "pending":
-					taskRevset = "tasks_pending()"
-				case "todo":
-					taskRevset = "tasks_todo()"
-				case "wip":
-					taskRevset = "tasks_wip()"
-				case "done":
-					taskRevset = "tasks_done()"
-				case "blocked":
-					taskRevset = "tasks_blocked()"
-				case "standby":
-					taskRevset = "tasks_standby()"
-				case "untested":
-					taskRevset = "tasks_untested()"
-				case "draft":
-					taskRevset = "tasks_draft()"
-				case "review":
-					taskRevset = "tasks_review()"
-				case "all":
-					taskRevset = "tasks()"
-				default:
-					return fmt.Errorf("unknown status %q", status)
-				}
+			taskRevset, err := statusRevset(findStatus)
+			if err != nil {
+				return err
 			}
 			// Show tasks + @ (jj handles elision for gaps in history)
-			if status == "done" || status == "all" {
+			if findStatus == "done" || findStatus == "all" {
 				revset = taskRevset
 			} else {
 				revset = fmt.Sprintf("%s | @", taskRevset)
@@ -103,8 +147,19 @@ Examples:
 
 		isMulti := len(repos) > 1
 
+		filter, err := findMetaFilter()
+		if err != nil {
+			return err
+		}
+		if findStatus == "ready" && !customRevset {
+			filter = readyFilter(filter)
+		}
+
+		if findMerged {
+			return findMergedView(repos, workspaceRoot, revset, filter)
+		}
 		if findFormat == "json" {
-			return findJSON(repos, workspaceRoot, revset, isMulti)
+			return findJSON(repos, workspaceRoot, revset, isMulti, filter)
 		}
 
 		// Text output
@@ -113,124 +168,237 @@ Examples:
 			fmt.Println()
 		}
 
-		PrintTasksWithRevset(repos, workspaceRoot, revset)
+		printTasks(repos, workspaceRoot, revset, filter)
 		return nil
 	},
 }
 
+// statusRevset maps a find status to its revset alias; "" means pending
+func statusRevset(status string) (string, error) {
+	switch status {
+	case "", "pending":
+		return "tasks_pending()", nil
+	case "ready":
+		return "tasks_todo()", nil
+	case "all":
+		return "tasks()", nil
+	}
+	if slices.Contains(validFlags, status) {
+		return "tasks_" + status + "()", nil
+	}
+	return "", fmt.Errorf("unknown status %q", status)
+}
+
+// taskFilter selects tasks using their repo's task graph; nil matches everything
+type taskFilter func(*task.Graph, *task.Task) bool
+
+// readyFilter narrows next to tasks that are ready to start
+func readyFilter(next taskFilter) taskFilter {
+	return func(g *task.Graph, t *task.Task) bool {
+		return g.IsReady(t.ChangeID) && (next == nil || next(g, t))
+	}
+}
+
+// findMetaFilter builds a filter from --label, --assignee and --priority
+func findMetaFilter() (taskFilter, error) {
+	if len(findLabels) == 0 && findAssignee == "" && findPriority == "" {
+		return nil, nil
+	}
+	priority := ""
+	if findPriority != "" {
+		p, err := task.NormalizeTrailer(task.KeyPriority, findPriority)
+		if err != nil {
+			return nil, err
+		}
+		priority = p
+	}
+	labels := task.ParseLabels(strings.Join(findLabels, ","))
+
+	return func(_ *task.Graph, t *task.Task) bool {
+		if findAssignee != "" && t.Assignee != findAssignee {
+			return false
+		}
+		if priority != "" && t.Priority != priority {
+			return false
+		}
+		for _, l := range labels {
+			if !t.HasLabel(l) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// filterRevset narrows revset to the tasks matching filter, keeping
+// non-task revisions such as @ for context
+func filterRevset(c *jj.Client, revset string, filter taskFilter) (string, error) {
+	tasks, err := task.Load(c, revset)
+	if err != nil {
+		return "", err
+	}
+	g, err := loadGraph(c)
+	if err != nil {
+		return "", err
+	}
+	var ids []string
+	for _, t := range tasks {
+		if !t.IsTask() || filter(g, t) {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return "none()", nil
+	}
+	return fmt.Sprintf("(%s) & (%s)", revset, strings.Join(ids, " | ")), nil
+}
+
+// printCrossRepoLinks lists Depends-On links from the tasks in revset to
+// tasks in other repos, marking tasks blocked by an unfinished one
+func printCrossRepoLinks(w io.Writer, c *jj.Client, repo workspace.Repo, revset string) {
+	tasks, err := task.Load(c, revset)
+	if err != nil {
+		return
+	}
+	lookup := crossRepoLookup(c)
+	var lines []string
+	for _, t := range tasks {
+		if !task.IsPending(t) {
+			continue
+		}
+		for _, ref := range t.DependsOn {
+			dep := lookup(ref)
+			if dep == nil {
+				continue
+			}
+			state := ""
+			if !task.IsDone(dep.Flag) {
+				state = " (blocked)"
+			}
+			lines = append(lines, fmt.Sprintf("  %s:%s → %s [task:%s] %s%s",
+				workspace.DisplayName(repo), t.ChangeID, dep.Ref(), dep.Flag, dep.Title, state))
+		}
+	}
+	if len(lines) > 0 {
+		_, _ = fmt.Fprintln(w, "Depends on other repos:")
+		_, _ = fmt.Fprintln(w, strings.Join(lines, "\n"))
+	}
+}
+
 // PrintTasksWithRevset outputs tasks matching revset across repos
 func PrintTasksWithRevset(repos []workspace.Repo, workspaceRoot, revset string) {
-	isMulti := len(repos) > 1
+	printTasks(repos, workspaceRoot, revset, nil)
+}
 
-	for _, repo := range repos {
-		repoPath := workspace.ResolveRepoPath(repo, workspaceRoot)
+func printTasks(repos []workspace.Repo, workspaceRoot, revset string, filter taskFilter) {
+	isMulti := len(repos) > 1
 
+	_ = forEachRepo(repos, workspaceRoot, func(r repoRun) error {
 		if isMulti {
-			displayPath := workspace.RelativePath(repoPath)
-			fmt.Printf("=== %s: jj -R %s log ===\n", workspace.DisplayName(repo), displayPath)
+			displayPath := workspace.RelativePath(r.Path)
+			_, _ = fmt.Fprintf(r.Out, "=== %s: jj -R %s log ===\n", workspace.DisplayName(r.Repo), displayPath)
 		}
 
-		jjArgs := []string{}
-		if globals.Color != "" {
-			jjArgs = append(jjArgs, "--color", globals.Color)
-		} else if client.IsTTY {
-			jjArgs = append(jjArgs, "--color=always")
+		if isMulti {
+			qualifyIDs(r.Client, r.Repo)
+		}
+		repoRevset := revset
+		var output string
+		var err error
+		if filter != nil {
+			repoRevset, err = filterRevset(r.Client, revset, filter)
+		}
+		if err == nil {
+			output, err = r.Client.Output("log", "-r", repoRevset, "-T", "task_log")
 		}
-		jjArgs = append(jjArgs, "-R", repoPath, "log", "-r", revset, "-T", "task_log")
-
-		jjCmd := exec.Command("jj", jjArgs...)
-		jjCmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
-
-		output, err := jjCmd.Output()
 		if err != nil {
 			if isMulti {
-				if client.IsTTY {
-					fmt.Println("~  \033[32m(no tasks)\033[0m")
-				} else {
-					fmt.Println("~  (no tasks)")
-				}
+				printRepoPlaceholder(r.Out, "(no tasks)", err)
 			}
 		} else {
-			outStr := strings.TrimRight(string(output), "\n")
+			outStr := strings.TrimRight(output, "\n")
 			if outStr != "" {
-				fmt.Println(outStr)
+				_, _ = fmt.Fprintln(r.Out, outStr)
 			} else if isMulti {
-				if client.IsTTY {
-					fmt.Println("~  \033[32m(no tasks)\033[0m")
-				} else {
-					fmt.Println("~  (no tasks)")
-				}
+				printRepoPlaceholder(r.Out, "(no tasks)", nil)
+			}
+			if isMulti {
+				printCrossRepoLinks(r.Out, r.Client, r.Repo, repoRevset)
 			}
 		}
 
 		if isMulti {
-			fmt.Println()
+			_, _ = fmt.Fprintln(r.Out)
 		}
-	}
+		return nil
+	})
 }
 
 func init() {
-	findCmd.Flags().StringVarP(&findStatus, "status", "s", "", "Filter by status (pending, todo, wip, done, blocked, standby, untested, draft, review, all)")
+	findCmd.Flags().StringVarP(&findStatus, "status", "s", "", "Filter by status (pending, todo, wip, done, blocked, standby, untested, draft, review, ready, all)")
 	findCmd.Flags().StringVarP(&findRevset, "revset", "r", "", "Custom revset to filter tasks")
 	findCmd.Flags().StringVar(&findFormat, "format", "text", "Output format: text or json")
+	findCmd.Flags().StringSliceVar(&findLabels, "label", nil, "Only tasks with this label (repeatable, all must match)")
+	findCmd.Flags().StringVar(&findAssignee, "assignee", "", "Only tasks assigned to this person or agent")
+	findCmd.Flags().StringVar(&findPriority, "priority", "", "Only tasks with this priority ("+strings.Join(task.Priorities, ", ")+")")
+	findCmd.Flags().StringVar(&findScope, "scope", "", "Where to look for tasks: all, a number of ancestors of @, or a base revset")
+	findCmd.Flags().BoolVar(&findMerged, "merged", false, "One list across all repos, sorted by status, with counts per repo and status")
 	rootCmd.AddCommand(findCmd)
+	addRepoFlags(findCmd)
 	_ = findCmd.RegisterFlagCompletionFunc("status", completeFindFlag)
+	_ = findCmd.RegisterFlagCompletionFunc("priority", cobra.FixedCompletions(task.Priorities, cobra.ShellCompDirectiveNoFileComp))
 }
 
-func findJSON(repos []workspace.Repo, workspaceRoot, revset string, isMulti bool) error {
-	var output FindOutput
-	taskFlagRe := regexp.MustCompile(`\[task:(\w+)\]`)
-
-	// Template: change_id \t empty \t working_copy \t description_first_line
-	tmpl := `change_id.shortest() ++ "\t" ++ if(empty, "true", "false") ++ "\t" ++ if(self.contained_in("@"), "true", "false") ++ "\t" ++ description.first_line() ++ "\n"`
+func findJSON(repos []workspace.Repo, workspaceRoot, revset string, isMulti bool, filter taskFilter) error {
+	items, err := findItems(repos, workspaceRoot, revset, isMulti, filter)
+	if err != nil {
+		return err
+	}
+	return encodeFind(FindOutput{Tasks: items})
+}
 
-	for _, repo := range repos {
-		repoPath := workspace.ResolveRepoPath(repo, workspaceRoot)
+// encodeFind prints output as find's JSON, filling in the count
+func encodeFind(output FindOutput) error {
+	output.Count = len(output.Tasks)
 
-		jjArgs := []string{"--color=never", "-R", repoPath, "log", "-r", revset, "--no-graph", "-T", tmpl}
-		jjCmd := exec.Command("jj", jjArgs...)
-		jjCmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
 
-		out, err := jjCmd.Output()
+// findItems loads the revisions in revset from every repo, in repo order,
+// naming the repo on each item when withRepo is set
+func findItems(repos []workspace.Repo, workspaceRoot, revset string, withRepo bool, filter taskFilter) ([]TaskItem, error) {
+	perRepo := make([][]TaskItem, len(repos))
+	err := forEachRepo(repos, workspaceRoot, func(r repoRun) error {
+		tasks, err := task.Load(r.Client, revset)
 		if err != nil {
-			continue
+			return nil
+		}
+		g, err := loadGraph(r.Client)
+		if err != nil {
+			return nil
 		}
 
-		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-		for _, line := range lines {
-			if line == "" {
+		for _, t := range tasks {
+			if filter != nil && t.IsTask() && !filter(g, t) {
 				continue
 			}
-			parts := strings.SplitN(line, "\t", 4)
-			if len(parts) < 4 {
-				continue
-			}
-
-			item := TaskItem{
-				ChangeID:    parts[0],
-				Empty:       parts[1] == "true",
-				WorkingCopy: parts[2] == "true",
-			}
-
-			firstLine := parts[3]
-			if match := taskFlagRe.FindStringSubmatch(firstLine); match != nil {
-				item.Flag = match[1]
-				item.Title = strings.TrimSpace(taskFlagRe.ReplaceAllString(firstLine, ""))
-			} else {
-				item.Title = firstLine
-			}
-
-			if isMulti {
-				item.Repo = workspace.DisplayName(repo)
+			item := newTaskItem(t)
+			item.BlockedBy = task.IDs(g.UnmetDependencies(t.ChangeID))
+			if withRepo {
+				item.Repo = workspace.DisplayName(r.Repo)
 			}
-
-			output.Tasks = append(output.Tasks, item)
+			perRepo[r.Index] = append(perRepo[r.Index], item)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	output.Count = len(output.Tasks)
-
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(output)
+	var items []TaskItem
+	for _, repoItems := range perRepo {
+		items = append(items, repoItems...)
+	}
+	return items, nil
 }
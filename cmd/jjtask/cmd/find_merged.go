@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"jjtask/internal/task"
+	"jjtask/internal/workspace"
+)
+
+// FindSummary counts the tasks listed by find --merged
+type FindSummary struct {
+	ByRepo   map[string]int `json:"by_repo"`
+	ByStatus map[string]int `json:"by_status"`
+}
+
+// mergedStatusOrder lists statuses most active first for find --merged.
+// Custom statuses follow, and done statuses come last.
+var mergedStatusOrder = []string{"wip", "review", "untested", "blocked", "todo", "draft", "standby"}
+
+// statusRank is flag's position in find --merged
+func statusRank(flag string) int {
+	if task.IsDone(flag) {
+		return len(mergedStatusOrder) + len(validFlags)
+	}
+	if i := slices.Index(mergedStatusOrder, flag); i >= 0 {
+		return i
+	}
+	if i := slices.Index(validFlags, flag); i >= 0 {
+		return len(mergedStatusOrder) + i
+	}
+	return len(mergedStatusOrder) + len(validFlags) - 1
+}
+
+// mergeItems keeps the tasks from items, sorted by status and then by repo
+// and jj log order
+func mergeItems(items []TaskItem) []TaskItem {
+	var tasks []TaskItem
+	for _, item := range items {
+		if item.Flag != "" {
+			tasks = append(tasks, item)
+		}
+	}
+	slices.SortStableFunc(tasks, func(a, b TaskItem) int {
+		return cmp.Compare(statusRank(a.Flag), statusRank(b.Flag))
+	})
+	return tasks
+}
+
+// summarize counts tasks per repo, listing every repo, and per status
+func summarize(tasks []TaskItem, repos []workspace.Repo) FindSummary {
+	summary := FindSummary{ByRepo: make(map[string]int), ByStatus: make(map[string]int)}
+	for _, repo := range repos {
+		summary.ByRepo[workspace.DisplayName(repo)] = 0
+	}
+	for _, t := range tasks {
+		summary.ByRepo[t.Repo]++
+		summary.ByStatus[t.Flag]++
+	}
+	return summary
+}
+
+// findMergedView prints the tasks of every repo as one list
+func findMergedView(repos []workspace.Repo, workspaceRoot, revset string, filter taskFilter) error {
+	items, err := findItems(repos, workspaceRoot, revset, true, filter)
+	if err != nil {
+		return err
+	}
+	tasks := mergeItems(items)
+	summary := summarize(tasks, repos)
+
+	if findFormat == "json" {
+		return encodeFind(FindOutput{Tasks: tasks, Summary: &summary})
+	}
+	if hint := workspace.ContextHint(); hint != "" {
+		fmt.Println(hint)
+		fmt.Println()
+	}
+	printMerged(os.Stdout, tasks, summary, repos)
+	return nil
+}
+
+// printMerged writes tasks as aligned rows of status, repo-qualified ID and
+// title, then the summary counts
+func printMerged(w io.Writer, tasks []TaskItem, summary FindSummary, repos []workspace.Repo) {
+	flagWidth, idWidth := 0, 0
+	for _, t := range tasks {
+		flagWidth = max(flagWidth, len("[task:"+t.Flag+"]"))
+		idWidth = max(idWidth, len(t.Repo+":"+t.ChangeID))
+	}
+
+	if len(tasks) == 0 {
+		_, _ = fmt.Fprintln(w, "(no tasks)")
+	}
+	for _, t := range tasks {
+		flag := fmt.Sprintf("%-*s", flagWidth, "[task:"+t.Flag+"]")
+		if code := flagANSI[t.Flag]; client.IsTTY && code != "" {
+			flag = "\033[" + code + "m" + flag + "\033[0m"
+		}
+		line := fmt.Sprintf("%s  %-*s  %s", flag, idWidth, t.Repo+":"+t.ChangeID, t.Title)
+		if len(t.BlockedBy) > 0 {
+			line += " (blocked by " + strings.Join(t.BlockedBy, ", ") + ")"
+		}
+		_, _ = fmt.Fprintln(w, line)
+	}
+
+	var byRepo, byStatus []string
+	for _, repo := range repos {
+		name := workspace.DisplayName(repo)
+		byRepo = append(byRepo, fmt.Sprintf("%s %d", name, summary.ByRepo[name]))
+	}
+	statuses := slices.SortedFunc(maps.Keys(summary.ByStatus), func(a, b string) int {
+		return cmp.Compare(statusRank(a), statusRank(b))
+	})
+	for _, status := range statuses {
+		byStatus = append(byStatus, fmt.Sprintf("%s %d", status, summary.ByStatus[status]))
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "By repo:   %s\n", strings.Join(byRepo, ", "))
+	if len(byStatus) > 0 {
+		_, _ = fmt.Fprintf(w, "By status: %s\n", strings.Join(byStatus, ", "))
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"jjtask/internal/jj"
+	"jjtask/internal/workspace"
+)
+
+func TestPrintMerged(t *testing.T) {
+	useFake(t)
+	useStatusConfig(t, "[[statuses.custom]]\nname = \"qa\"\n")
+	if err := applyStatuses(&jj.Client{}); err != nil {
+		t.Fatal(err)
+	}
+	repos := []workspace.Repo{{Path: "api"}, {Path: "web"}, {Path: "docs"}}
+	items := []TaskItem{
+		{ChangeID: "a1", Flag: "todo", Title: "API todo", Repo: "api"},
+		{ChangeID: "at", Title: "working copy", Repo: "api", WorkingCopy: true},
+		{ChangeID: "a2", Flag: "done", Title: "API done", Repo: "api"},
+		{ChangeID: "w1", Flag: "qa", Title: "Web qa", Repo: "web"},
+		{ChangeID: "w22", Flag: "wip", Title: "Web wip", Repo: "web"},
+		{ChangeID: "w3", Flag: "todo", Title: "Web todo", Repo: "web", BlockedBy: []string{"api:a1"}},
+	}
+
+	tasks := mergeItems(items)
+	var out strings.Builder
+	printMerged(&out, tasks, summarize(tasks, repos), repos)
+	want := `[task:wip]   web:w22  Web wip
+[task:todo]  api:a1   API todo
+[task:todo]  web:w3   Web todo (blocked by api:a1)
+[task:qa]    web:w1   Web qa
+[task:done]  api:a2   API done
+
+By repo:   api 2, web 3, docs 0
+By status: wip 1, todo 2, qa 1, done 1
+`
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -2,27 +2,27 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
-)
 
-var validFlags = []string{"draft", "todo", "wip", "untested", "standby", "review", "blocked", "done"}
+	"jjtask/internal/task"
+)
 
 var (
-	flagRev string
+	flagRev   string
+	flagForce bool
 )
 
 var flagCmd = &cobra.Command{
-	Use:       "flag [REV] <status>",
-	Short:     "Update task status flag",
-	ValidArgs: validFlags,
+	Use:   "flag [REV] <status>",
+	Short: "Update task status flag",
 	Long: `Update the [task:*] flag in a revision description.
 
-Valid flags: draft, todo, wip, untested, standby, review, blocked, done
+Valid flags: draft, todo, wip, untested, standby, review, blocked, done,
+plus custom statuses from [[statuses.custom]] in .jjtask.toml. Changes not
+allowed by [statuses.transitions] are refused unless --force is given.
 
 Examples:
   jjtask flag wip
@@ -44,256 +44,287 @@ Examples:
 		if !slices.Contains(validFlags, toFlag) {
 			return fmt.Errorf("invalid flag %q, must be one of: %s", toFlag, strings.Join(validFlags, ", "))
 		}
+		if !flagForce {
+			if err := checkTransition(rev, toFlag); err != nil {
+				return err
+			}
+		}
 
 		// Check for pending children and empty task when marking done
-		if toFlag == "done" {
-			checkPendingChildren(cmd, rev)
-			checkEmptyTask(cmd, rev)
+		if task.IsDone(toFlag) {
+			if err := checkPendingChildren(cmd, rev); err != nil {
+				return err
+			}
+			if err := checkEmptyTask(cmd, rev); err != nil {
+				return err
+			}
 		}
 
-		// Check for blocked ancestors, done ancestors, and existing WIP when marking wip
+		// Check for blocked ancestors, unfinished dependencies, done ancestors, and existing WIP when marking wip
 		if toFlag == "wip" {
-			checkBlockedAncestors(cmd, rev)
-			checkDoneAncestors(cmd, rev)
-			checkExistingWip(cmd, rev)
+			for _, check := range []func(*cobra.Command, string) error{checkBlockedAncestors, checkUnmetDependencies, checkDoneAncestors, checkExistingWip} {
+				if err := check(cmd, rev); err != nil {
+					return err
+				}
+			}
 		}
 
-		desc, err := client.GetDescription(rev)
-		if err != nil {
-			return fmt.Errorf("failed to get description: %w", err)
+		if err := applyFlag(cmd, rev, toFlag); err != nil {
+			return err
 		}
 
-		// Detect current flag
-		taskPattern := regexp.MustCompile(`^\[task:(\w+)\]`)
-		match := taskPattern.FindStringSubmatch(desc)
-
-		var newDesc string
-		if match == nil {
-			// No current flag - prepend the new one
-			newDesc = fmt.Sprintf("[task:%s] %s", toFlag, desc)
-		} else {
-			// Replace old flag with new
-			newDesc = taskPattern.ReplaceAllString(desc, fmt.Sprintf("[task:%s]", toFlag))
-		}
-
-		if err := client.SetDescription(rev, newDesc); err != nil {
-			return fmt.Errorf("failed to set description: %w", err)
-		}
-
-		// Check if @ has uncommitted work and is not the task being marked
-		checkWorkingCopyDiff(cmd, rev, toFlag)
-
-		fmt.Fprintln(os.Stderr, "Tip: Consider using 'jjtask wip' or 'jjtask done' for the mega-merge workflow")
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Tip: Consider using 'jjtask wip' or 'jjtask done' for the mega-merge workflow")
 
 		return nil
 	},
 }
 
-// checkExistingWip warns when marking a new task as WIP while another WIP exists
-// Returns error if the new WIP task is not in the same chain as existing WIP
-func checkExistingWip(cmd *cobra.Command, newWipRev string) {
-	// Find existing WIP tasks (excluding the one we're about to mark)
-	wipOutput, err := client.Query("log", "-r", fmt.Sprintf("tasks_wip() ~ %s", newWipRev), "--no-graph", "-T", `change_id.shortest() ++ " " ++ description.first_line() ++ "\n"`)
-	if err != nil || strings.TrimSpace(wipOutput) == "" {
-		return // No other WIP task
+// checkExistingWip warns when marking a new task as WIP while another WIP
+// exists in a different branch
+func checkExistingWip(cmd *cobra.Command, newWipRev string) error {
+	g, err := taskGraph()
+	if err != nil {
+		return nil
+	}
+	target, err := g.Resolve(newWipRev)
+	if err != nil {
+		return nil
 	}
 
-	wipLine := strings.TrimSpace(strings.Split(wipOutput, "\n")[0])
-	wipParts := strings.SplitN(wipLine, " ", 2)
-	if len(wipParts) == 0 {
-		return
+	// Find existing WIP tasks (excluding the one we're about to mark)
+	var wip *task.Task
+	for _, t := range g.WithFlag("wip") {
+		if t.ChangeID != target.ChangeID {
+			wip = t
+			break
+		}
 	}
-	wipID := wipParts[0]
-	wipTitle := ""
-	if len(wipParts) > 1 {
-		wipTitle = wipParts[1]
+	if wip == nil {
+		return nil // No other WIP task
 	}
+	wipID := wip.ChangeID
 
 	// Check if new WIP is ancestor or descendant of existing WIP (same chain)
-	checkRevset := fmt.Sprintf("(%s & (ancestors(%s) | descendants(%s)))", newWipRev, wipID, wipID)
-	result, err := client.Query("log", "-r", checkRevset, "--no-graph", "-T", "change_id.shortest()", "--limit", "1")
-	if err == nil && strings.TrimSpace(result) != "" {
-		return // In same chain, OK
+	if g.IsAncestorOf(target.ChangeID, wipID) || g.IsAncestorOf(wipID, target.ChangeID) {
+		return nil // In same chain, OK
 	}
 
 	// Not in same chain - warn
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintf(stderr, "⚠️  Another WIP task exists: %s %s\n", wipID, wipTitle)
-	_, _ = fmt.Fprintln(stderr, "Multiple WIP tasks in different branches can be confusing.")
-	_, _ = fmt.Fprintln(stderr, "Options:")
-	_, _ = fmt.Fprintf(stderr, "  • Pause existing: jjtask flag blocked -r %s\n", wipID)
-	_, _ = fmt.Fprintf(stderr, "  • Switch to existing: jj edit %s\n", wipID)
-	_, _ = fmt.Fprintf(stderr, "  • Rebase to chain: jj rebase -s %s -d %s\n", newWipRev, wipID)
-	_, _ = fmt.Fprintln(stderr)
+	suggestions := []string{
+		"jjtask flag blocked -r " + wipID,
+		"jj edit " + wipID,
+		fmt.Sprintf("jj rebase -s %s -d %s", newWipRev, wipID),
+	}
+	return warn(cmd, &Warning{
+		Code:        WarnExistingWip,
+		Severity:    SeverityWarning,
+		Message:     fmt.Sprintf("Another WIP task exists: %s %s", wipID, wip.FirstLine()),
+		Revisions:   []string{wipID, target.ChangeID},
+		Suggestions: suggestions,
+		body: []string{
+			"Multiple WIP tasks in different branches can be confusing.",
+			"Options:",
+			"  • Pause existing: " + suggestions[0],
+			"  • Switch to existing: " + suggestions[1],
+			"  • Rebase to chain: " + suggestions[2],
+		},
+	})
 }
 
-// checkDoneAncestors warns if any ancestor task is done
-func checkDoneAncestors(cmd *cobra.Command, taskRev string) {
-	// Get done ancestors (tasks with [task:done] prefix)
-	doneRevset := fmt.Sprintf("ancestors(%s) & tasks_done()", taskRev)
-	output, err := client.Query("log", "-r", doneRevset, "--no-graph", "-T", `change_id.shortest() ++ " " ++ description.first_line() ++ "\n"`, "--limit", "3")
+// ancestorsWithFlag returns rev and its ancestors carrying one of flags, like
+// ancestors(rev) & tasks_<flag>()
+func ancestorsWithFlag(rev string, flags ...string) []*task.Task {
+	g, err := taskGraph()
 	if err != nil {
-		return
+		return nil
 	}
-	output = strings.TrimSpace(output)
-	if output == "" {
-		return
+	t, err := g.Resolve(rev)
+	if err != nil {
+		return nil
 	}
-
-	lines := strings.Split(output, "\n")
-	if len(lines) == 0 {
-		return
+	var result []*task.Task
+	for _, a := range append([]*task.Task{t}, g.Ancestors(t.ChangeID)...) {
+		if slices.Contains(flags, a.Flag) {
+			result = append(result, a)
+		}
 	}
+	return result
+}
 
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintln(stderr, "⚠️  Ancestor task is already done:")
-	for _, line := range lines {
-		_, _ = fmt.Fprintf(stderr, "  • %s\n", line)
+// taskBullets lists tasks as warning body lines
+func taskBullets(tasks []*task.Task) []string {
+	var lines []string
+	for _, t := range tasks {
+		lines = append(lines, fmt.Sprintf("  • %s %s", t.Ref(), t.FirstLine()))
 	}
-	_, _ = fmt.Fprintln(stderr, "Starting work below done tasks is unusual. Consider:")
-	_, _ = fmt.Fprintln(stderr, "  • Rebase as sibling: jj rebase -s", taskRev, "-d <done-task>~")
-	_, _ = fmt.Fprintln(stderr, "  • Or squash done tasks: jjtask squash -r <done-task>")
-	_, _ = fmt.Fprintln(stderr)
+	return lines
 }
 
-// checkBlockedAncestors warns if any ancestor task is blocked
-func checkBlockedAncestors(cmd *cobra.Command, taskRev string) {
-	// Get blocked ancestors
-	blockedRevset := fmt.Sprintf("ancestors(%s) & tasks_blocked()", taskRev)
-	output, err := client.Query("log", "-r", blockedRevset, "--no-graph", "-T", `change_id.shortest() ++ " " ++ description.first_line() ++ "\n"`)
-	if err != nil {
-		return
+// checkDoneAncestors warns if any ancestor task is done
+func checkDoneAncestors(cmd *cobra.Command, taskRev string) error {
+	done := ancestorsWithFlag(taskRev, task.DoneFlags...)
+	if len(done) == 0 {
+		return nil
 	}
-	output = strings.TrimSpace(output)
-	if output == "" {
-		return
+	if len(done) > 3 {
+		done = done[:3]
 	}
 
-	lines := strings.Split(output, "\n")
-	if len(lines) == 0 {
-		return
+	suggestions := []string{
+		"jj rebase -s " + taskRev + " -d <done-task>~",
+		"jjtask squash -r <done-task>",
 	}
+	return warn(cmd, &Warning{
+		Code:        WarnDoneAncestor,
+		Severity:    SeverityWarning,
+		Message:     "Ancestor task is already done:",
+		Revisions:   task.IDs(done),
+		Suggestions: suggestions,
+		body: append(taskBullets(done),
+			"Starting work below done tasks is unusual. Consider:",
+			"  • Rebase as sibling: "+suggestions[0],
+			"  • Or squash done tasks: "+suggestions[1],
+		),
+	})
+}
 
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintln(stderr, "⚠️  Ancestor task is blocked:")
-	for _, line := range lines {
-		_, _ = fmt.Fprintf(stderr, "  • %s\n", line)
+// checkBlockedAncestors warns if any ancestor task is blocked
+func checkBlockedAncestors(cmd *cobra.Command, taskRev string) error {
+	blocked := ancestorsWithFlag(taskRev, "blocked")
+	if len(blocked) == 0 {
+		return nil
 	}
-	_, _ = fmt.Fprintln(stderr, "Consider unblocking the ancestor first.")
-	_, _ = fmt.Fprintln(stderr)
+
+	return warn(cmd, &Warning{
+		Code:      WarnBlockedAncestor,
+		Severity:  SeverityWarning,
+		Message:   "Ancestor task is blocked:",
+		Revisions: task.IDs(blocked),
+		body:      append(taskBullets(blocked), "Consider unblocking the ancestor first."),
+	})
 }
 
-// checkPendingChildren warns if task has pending child tasks
-func checkPendingChildren(cmd *cobra.Command, taskRev string) {
-	// Get pending children (tasks that are not done)
-	pendingRevset := fmt.Sprintf("children(%s) & tasks_pending()", taskRev)
-	output, err := client.Query("log", "-r", pendingRevset, "--no-graph", "-T", `change_id.shortest() ++ " " ++ description.first_line() ++ "\n"`)
+// checkPendingChildren warns if task has pending child tasks. Children in
+// except are about to be marked done too.
+func checkPendingChildren(cmd *cobra.Command, taskRev string, except ...string) error {
+	g, err := taskGraph()
 	if err != nil {
-		return
+		return nil
 	}
-	output = strings.TrimSpace(output)
-	if output == "" {
-		return
+	t, err := g.Resolve(taskRev)
+	if err != nil {
+		return nil
 	}
-
-	lines := strings.Split(output, "\n")
-	if len(lines) == 0 {
-		return
+	var pending []*task.Task
+	for _, child := range g.Children(t.ChangeID) {
+		if task.IsPending(child) && !slices.Contains(except, child.ChangeID) {
+			pending = append(pending, child)
+		}
 	}
-
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintf(stderr, "⚠️  Task has %d pending children:\n", len(lines))
-	for _, line := range lines {
-		_, _ = fmt.Fprintf(stderr, "  • %s\n", line)
+	if len(pending) == 0 {
+		return nil
 	}
-	_, _ = fmt.Fprintln(stderr, "Consider marking children done first, or they may be orphaned.")
-	_, _ = fmt.Fprintln(stderr)
+
+	return warn(cmd, &Warning{
+		Code:      WarnPendingChildren,
+		Severity:  SeverityWarning,
+		Message:   fmt.Sprintf("Task has %d pending children:", len(pending)),
+		Revisions: append([]string{t.ChangeID}, task.IDs(pending)...),
+		body:      append(taskBullets(pending), "Consider marking children done first, or they may be orphaned."),
+	})
 }
 
 // checkEmptyTask warns if marking an empty revision as done
-func checkEmptyTask(cmd *cobra.Command, taskRev string) {
-	diff, err := client.Query("diff", "-r", taskRev, "--stat")
+func checkEmptyTask(cmd *cobra.Command, taskRev string) error {
+	g, err := taskGraph()
 	if err != nil {
-		return
+		return nil
 	}
-	diff = strings.TrimSpace(diff)
-	if diff != "" && !strings.HasPrefix(diff, "0 files changed") {
-		return // has content
+	t, err := g.Resolve(taskRev)
+	if err != nil || !t.Empty {
+		return nil // has content
 	}
 
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintln(stderr, "⚠️  Task is empty - no changes to mark done")
-	_, _ = fmt.Fprintln(stderr, "If this is a planning-only task, this warning can be ignored.")
-	_, _ = fmt.Fprintln(stderr)
+	return warn(cmd, &Warning{
+		Code:      WarnEmptyTask,
+		Severity:  SeverityWarning,
+		Message:   "Task is empty - no changes to mark done",
+		Revisions: []string{t.ChangeID},
+		body:      []string{"If this is a planning-only task, this warning can be ignored."},
+	})
 }
 
 // checkWorkingCopyDiff warns if @ has changes that might belong to the task
-func checkWorkingCopyDiff(cmd *cobra.Command, taskRev, _flag string) {
-	// Get @ change id
-	atID, err := client.Query("log", "-r", "@", "--no-graph", "-T", "change_id.shortest()")
+func checkWorkingCopyDiff(cmd *cobra.Command, taskRev, _flag string) error {
+	g, err := taskGraph()
 	if err != nil {
-		return
+		return nil
 	}
-	atID = strings.TrimSpace(atID)
-
-	// Get task change id
-	taskID, err := client.Query("log", "-r", taskRev, "--no-graph", "-T", "change_id.shortest()")
+	t, err := g.Resolve(taskRev)
 	if err != nil {
-		return
+		return nil
 	}
-	taskID = strings.TrimSpace(taskID)
-
 	// If @ is the task, no warning needed
-	if atID == taskID {
-		return
+	at := g.WorkingCopy()
+	if t.WorkingCopy || at == nil || at.Empty {
+		return nil
 	}
 
-	// Check if @ has a diff (actual file changes, not just the summary line)
+	// Show the actual file changes
 	diff, err := client.Query("diff", "-r", "@", "--stat")
 	if err != nil {
-		return
+		return nil
 	}
 	diff = strings.TrimSpace(diff)
 	// Empty diff or only summary line with "0 files changed" means no real changes
 	if diff == "" || strings.HasPrefix(diff, "0 files changed") {
-		return
+		return nil
 	}
 
 	// @ has changes and is not the task - warn
-	stderr := cmd.ErrOrStderr()
-	_, _ = fmt.Fprintln(stderr)
-	_, _ = fmt.Fprintln(stderr, "⚠️  Working copy (@) has uncommitted changes:")
-	_, _ = fmt.Fprintln(stderr, diff)
-	_, _ = fmt.Fprintln(stderr, "Were any of these changes part of this task?")
-	_, _ = fmt.Fprintln(stderr)
+	return warn(cmd, &Warning{
+		Code:        WarnWorkingCopyDiff,
+		Severity:    SeverityWarning,
+		Message:     "Working copy (@) has uncommitted changes:",
+		Revisions:   []string{at.ChangeID, t.ChangeID},
+		Suggestions: []string{fmt.Sprintf("jj squash --from @ --into %s", t.ChangeID)},
+		body:        []string{diff, "Were any of these changes part of this task?"},
+	})
+}
+
+// applyFlag sets the flag, then checks if @ has uncommitted work and is not
+// the task being marked. @ is only snapshotted by the describe, so the check
+// runs after it in one transaction: a warning escalated to an error undoes
+// the flag.
+func applyFlag(cmd *cobra.Command, rev, toFlag string) error {
+	return client.Transaction(func() error {
+		if err := setTaskFlag(rev, toFlag); err != nil {
+			return fmt.Errorf("failed to set description: %w", err)
+		}
+		return checkWorkingCopyDiff(cmd, rev, toFlag)
+	})
 }
 
 // setTaskFlag is a helper to update a task's flag in its description
 func setTaskFlag(rev, flag string) error {
-	desc, err := client.GetDescription(rev)
+	g, err := taskGraph()
 	if err != nil {
 		return err
 	}
-
-	taskPattern := regexp.MustCompile(`^\[task:(\w+)\]`)
-	var newDesc string
-	if taskPattern.MatchString(desc) {
-		newDesc = taskPattern.ReplaceAllString(desc, fmt.Sprintf("[task:%s]", flag))
-	} else {
-		newDesc = fmt.Sprintf("[task:%s] %s", flag, desc)
+	t, err := g.Resolve(rev)
+	if err != nil {
+		return err
 	}
-	return client.SetDescription(rev, newDesc)
+	return client.SetDescription(t.ID, task.SetFlag(t.Description, flag))
 }
 
 func init() {
+	withResultFormat(flagCmd)
+	withRepoRefs(flagCmd, &flagRev)
 	rootCmd.AddCommand(flagCmd)
 
 	flagCmd.Flags().StringVarP(&flagRev, "rev", "r", "@", "revision to update")
+	flagCmd.Flags().BoolVar(&flagForce, "force", false, "Allow changes not listed in [statuses.transitions]")
 
 	flagCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/jj"
+)
+
+func TestSetTaskFlagAsksJJForPrefixes(t *testing.T) {
+	fake := useFake(t)
+	// "kx" is a bookmark as far as jj is concerned, not a prefix of kxq
+	fake.On("log", "-r", "kx").Returns(logLines(t, "bm base: [task:todo] Bookmarked"))
+	fake.On("log").Returns(logLines(t, "at kxq: work", "kxq base: [task:todo] X", "base: Base"))
+	fake.On("describe")
+
+	if err := setTaskFlag("kx", "wip"); err != nil {
+		t.Fatal(err)
+	}
+	calls := fake.Calls()
+	last := calls[len(calls)-1]
+	if last.String() != "describe -r bm --stdin" || !strings.HasPrefix(last.Stdin, "[task:wip] Bookmarked") {
+		t.Errorf("last call = %q with %q, want bm described with its own description", last, last.Stdin)
+	}
+}
+
+func TestCustomDoneStatus(t *testing.T) {
+	useStatusConfig(t, "[[statuses.custom]]\nname = \"shipped\"\ndone = true\n")
+	if err := applyStatuses(&jj.Client{}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("done ancestor", func(t *testing.T) {
+		fake := useFake(t)
+		fake.On("log").Returns(logLines(t, "at x: work", "x s: [task:todo] X", "s base: [task:shipped] S", "base: Base"))
+		var stderr strings.Builder
+		cmd := &cobra.Command{}
+		cmd.SetErr(&stderr)
+		if err := checkDoneAncestors(cmd, "x"); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stderr.String(), "Ancestor task is already done") || !strings.Contains(stderr.String(), "S") {
+			t.Errorf("stderr = %q, want warning about shipped ancestor", stderr.String())
+		}
+	})
+
+	t.Run("orphan", func(t *testing.T) {
+		fake := useFake(t)
+		fake.On("log").Returns(logLines(t, "at base: work", "x base: [task:wip] X", "base: Base")).Once()
+		fake.On("describe")
+		fake.On("log").Returns(logLines(t, "at base: work", "x base: [task:shipped] X", "base: Base"))
+		var out strings.Builder
+		if err := runWithResult(flagCmd, &out, func() error { return setTaskFlag("x", "shipped") }); err != nil {
+			t.Fatal(err)
+		}
+		var result ResultOutput
+		if err := json.Unmarshal([]byte(out.String()), &result); err != nil {
+			t.Fatalf("decoding %q: %v", out.String(), err)
+		}
+		if !slices.Equal(result.Orphans, []string{"x"}) {
+			t.Errorf("orphans = %v, want shipped x outside @'s ancestry", result.Orphans)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var (
+	graphFormat string
+	graphAll    bool
+)
+
+// flagColors mirrors the [colors] palette in 10-jjtask.toml as fill colors
+var flagColors = map[string]string{
+	"done":     "#a6e3a1", // green
+	"todo":     "#f9e2af", // yellow
+	"wip":      "#89dceb", // cyan
+	"blocked":  "#f38ba8", // red
+	"standby":  "#d0d0d0", // bright black
+	"draft":    "#d0d0d0", // bright black, italic
+	"untested": "#f5c2e7", // magenta
+	"review":   "#89b4fa", // blue
+}
+
+// flagOrder fixes the order style definitions are written in
+var flagOrder = []string{"draft", "todo", "wip", "blocked", "standby", "untested", "review", "done"}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [--format mermaid|dot]",
+	Short: "Export the task DAG as Mermaid or Graphviz DOT",
+	Long: `Print the pending task DAG (or all tasks with --all) as a Mermaid
+flowchart or Graphviz DOT graph for design docs and PR descriptions.
+
+Nodes are colored by flag with the same palette as 'jjtask find'. Solid
+edges follow parent relationships, dashed edges are Depends-On links.
+WIP tasks and the working copy (@) are drawn with a heavy border.
+
+Examples:
+  jjtask graph                       # Mermaid (paste into a fenced block)
+  jjtask graph --format dot | dot -Tsvg > tasks.svg
+  jjtask graph --all`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		view := newDAGView(g, graphAll)
+
+		switch graphFormat {
+		case "mermaid":
+			renderMermaid(os.Stdout, view)
+		case "dot":
+			renderDot(os.Stdout, view)
+		default:
+			return fmt.Errorf("invalid format %q, must be mermaid or dot", graphFormat)
+		}
+		return nil
+	},
+}
+
+// dagView is the subset of the task graph that gets drawn
+type dagView struct {
+	nodes []*task.Task // parents first
+	at    string       // change ID of @
+	edges [][2]string  // parent, child
+	deps  [][2]string  // dependency, dependent
+}
+
+// newDAGView selects pending (or all) tasks plus @, connecting each node to
+// its closest drawn ancestors so non-task revisions in between are skipped
+func newDAGView(g *task.Graph, all bool) dagView {
+	var v dagView
+	included := make(map[string]bool)
+	keep := func(t *task.Task) bool {
+		return t.WorkingCopy || (t.IsTask() && (all || task.IsPending(t)))
+	}
+	nodes := g.Nodes()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		t := nodes[i]
+		if !keep(t) {
+			continue
+		}
+		included[t.ChangeID] = true
+		v.nodes = append(v.nodes, t)
+		if t.WorkingCopy {
+			v.at = t.ChangeID
+		}
+	}
+
+	for _, t := range v.nodes {
+		for _, p := range g.ClosestAncestors(t.ChangeID, keep) {
+			v.edges = append(v.edges, [2]string{p.ChangeID, t.ChangeID})
+		}
+		for _, d := range g.Dependencies(t.ChangeID) {
+			if d.Repo == "" && included[d.ChangeID] {
+				v.deps = append(v.deps, [2]string{d.ChangeID, t.ChangeID})
+			}
+		}
+	}
+	return v
+}
+
+// nodeLabel is the text shown for a node
+func nodeLabel(t *task.Task) string {
+	if !t.IsTask() {
+		return fmt.Sprintf("@ %s %s", t.ChangeID, t.FirstLine())
+	}
+	return fmt.Sprintf("%s [%s] %s", t.ChangeID, t.Flag, t.Title)
+}
+
+// highlighted reports whether a node gets a heavy border
+func (v dagView) highlighted(t *task.Task) bool {
+	return t.ChangeID == v.at || t.Flag == "wip"
+}
+
+func renderMermaid(w io.Writer, v dagView) {
+	_, _ = fmt.Fprintln(w, "flowchart TD")
+	for _, t := range v.nodes {
+		label := strings.ReplaceAll(nodeLabel(t), `"`, "#quot;")
+		_, _ = fmt.Fprintf(w, "  %s[\"%s\"]\n", t.ChangeID, label)
+	}
+	for _, e := range v.edges {
+		_, _ = fmt.Fprintf(w, "  %s --> %s\n", e[0], e[1])
+	}
+	for _, e := range v.deps {
+		_, _ = fmt.Fprintf(w, "  %s -.-> %s\n", e[0], e[1])
+	}
+
+	for _, flag := range flagOrder {
+		style := "fill:" + flagColors[flag] + ",color:#000"
+		if flag == "draft" {
+			style += ",font-style:italic"
+		}
+		_, _ = fmt.Fprintf(w, "  classDef %s %s\n", flag, style)
+	}
+	_, _ = fmt.Fprintln(w, "  classDef current stroke:#000,stroke-width:3px")
+	for _, t := range v.nodes {
+		if t.IsTask() {
+			_, _ = fmt.Fprintf(w, "  class %s %s\n", t.ChangeID, t.Flag)
+		}
+		if v.highlighted(t) {
+			_, _ = fmt.Fprintf(w, "  class %s current\n", t.ChangeID)
+		}
+	}
+}
+
+func renderDot(w io.Writer, v dagView) {
+	_, _ = fmt.Fprintln(w, "digraph tasks {")
+	_, _ = fmt.Fprintln(w, "  rankdir=TB;")
+	_, _ = fmt.Fprintln(w, `  node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica"];`)
+	for _, t := range v.nodes {
+		label := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(nodeLabel(t))
+		attrs := fmt.Sprintf(`label="%s"`, label)
+		if color, ok := flagColors[t.Flag]; ok {
+			attrs += fmt.Sprintf(`, fillcolor="%s"`, color)
+		}
+		if t.Flag == "draft" {
+			attrs += `, fontname="Helvetica-Oblique"`
+		}
+		if v.highlighted(t) {
+			attrs += ", penwidth=3"
+		}
+		_, _ = fmt.Fprintf(w, "  %q [%s];\n", t.ChangeID, attrs)
+	}
+	for _, e := range v.edges {
+		_, _ = fmt.Fprintf(w, "  %q -> %q;\n", e[0], e[1])
+	}
+	for _, e := range v.deps {
+		_, _ = fmt.Fprintf(w, "  %q -> %q [style=dashed];\n", e[0], e[1])
+	}
+	_, _ = fmt.Fprintln(w, "}")
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", "mermaid", "Output format: mermaid or dot")
+	graphCmd.Flags().BoolVar(&graphAll, "all", false, "Include done tasks")
+	rootCmd.AddCommand(graphCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"jjtask/internal/task"
+)
+
+func testDAGView(t *testing.T) dagView {
+	t.Helper()
+	nodes, err := task.Parse(logLines(t,
+		"at w: work",
+		"w b: [task:wip] Say \"hi\"",
+		"c b: [task:todo] C\n\nDepends-On: w\n",
+		"b base: [task:done] B",
+		"base: Base",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newDAGView(task.NewGraph(nodes), false)
+}
+
+func TestRenderMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	renderMermaid(&buf, testDAGView(t))
+
+	want := `flowchart TD
+  c["c [todo] C"]
+  w["w [wip] Say #quot;hi#quot;"]
+  at["@ at work"]
+  w --> at
+  w -.-> c
+  classDef draft fill:#d0d0d0,color:#000,font-style:italic
+  classDef todo fill:#f9e2af,color:#000
+  classDef wip fill:#89dceb,color:#000
+  classDef blocked fill:#f38ba8,color:#000
+  classDef standby fill:#d0d0d0,color:#000
+  classDef untested fill:#f5c2e7,color:#000
+  classDef review fill:#89b4fa,color:#000
+  classDef done fill:#a6e3a1,color:#000
+  classDef current stroke:#000,stroke-width:3px
+  class c todo
+  class w wip
+  class w current
+  class at current
+`
+	if got := buf.String(); got != want {
+		t.Errorf("mermaid:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderDot(t *testing.T) {
+	var buf bytes.Buffer
+	renderDot(&buf, testDAGView(t))
+
+	want := `digraph tasks {
+  rankdir=TB;
+  node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica"];
+  "c" [label="c [todo] C", fillcolor="#f9e2af"];
+  "w" [label="w [wip] Say \"hi\"", fillcolor="#89dceb", penwidth=3];
+  "at" [label="@ at work", penwidth=3];
+  "w" -> "at";
+  "w" -> "c" [style=dashed];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("dot:\n%s\nwant:\n%s", got, want)
+	}
+}
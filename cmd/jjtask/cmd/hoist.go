@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var hoistCmd = &cobra.Command{
@@ -23,20 +24,13 @@ Examples:
 		// ~(::@) excludes ancestors, ~(@::) excludes descendants
 		revset := "tasks_pending() & empty() & ~(::@ | @::)"
 
-		revsOut, err := client.Query("log", "-r", revset, "--no-graph", "-T", `change_id.shortest() ++ "\n"`)
+		tasks, err := task.Load(client, revset)
 		if err != nil {
 			return fmt.Errorf("failed to find tasks: %w", err)
 		}
 
-		var tasks []string
-		for _, line := range strings.Split(strings.TrimSpace(revsOut), "\n") {
-			if line != "" {
-				tasks = append(tasks, line)
-			}
-		}
-
 		if len(tasks) == 0 {
-			fmt.Println("No pending empty tasks to hoist")
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No pending empty tasks to hoist")
 			return nil
 		}
 
@@ -46,11 +40,12 @@ Examples:
 			return fmt.Errorf("failed to rebase: %w", err)
 		}
 
-		fmt.Printf("Hoisted %d task(s) onto @\n", len(tasks))
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Hoisted %d task(s) onto @\n", len(tasks))
 		return nil
 	},
 }
 
 func init() {
+	withResultFormat(hoistCmd)
 	rootCmd.AddCommand(hoistCmd)
 }
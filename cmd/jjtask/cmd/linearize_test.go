@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/jj/jjtest"
+	"jjtask/internal/task"
+)
+
+// logLines renders "id parents...: description" specs as task log output
+func logLines(t *testing.T, specs ...string) string {
+	t.Helper()
+	var lines []string
+	for _, spec := range specs {
+		ids, desc, _ := strings.Cut(spec, ":")
+		fields := strings.Fields(ids)
+		data, err := json.Marshal(map[string]any{
+			"id":           fields[0],
+			"change_id":    fields[0],
+			"parents":      fields[1:],
+			"empty":        true,
+			"working_copy": fields[0] == "at",
+			"description":  strings.TrimSpace(desc),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(data))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// useFake points the package client at a scripted jj for the test
+func useFake(t *testing.T) *jjtest.Fake {
+	t.Helper()
+	fake := jjtest.New()
+	fake.On("op", "log").Returns("op1\n")
+	fake.On("rebase")
+	prevClient, prevGraph := client, graph
+	client, graph = fake.Client(), nil
+	t.Cleanup(func() { client, graph = prevClient, prevGraph })
+	return fake
+}
+
+func TestLinearizeDoneTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     []string
+		parents []string
+		want    []string
+	}{
+		{
+			name: "task parents only",
+			log: []string{
+				"at d t1 t2: merge",
+				"t2 base: [task:wip] T2",
+				"t1 base: [task:wip] T1",
+				"d base: [task:done] D",
+				"base: Base",
+			},
+			parents: []string{"t1", "t2"},
+			want: []string{
+				"rebase -s t1 -o d",
+				"rebase -s t2 -o t1",
+				"rebase -s @ -o t2",
+			},
+		},
+		{
+			name: "tasks go on top of work",
+			log: []string{
+				"at d t1 w: merge",
+				"t1 base: [task:wip] T1",
+				"d base: [task:done] D",
+				"w base: work",
+				"base: Base",
+			},
+			parents: []string{"t1", "w"},
+			want: []string{
+				"rebase -s d -o w",
+				"rebase -s t1 -o d",
+				"rebase -s @ -o t1",
+			},
+		},
+		{
+			name: "newest work parent is the tip",
+			log: []string{
+				"at d w1 w2: merge",
+				"d base: [task:done] D",
+				"w2 w1: newer work",
+				"w1 base: older work",
+				"base: Base",
+			},
+			parents: []string{"w1", "w2"},
+			want: []string{
+				"rebase -s d -o w2",
+				"rebase -s @ -o d",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFake(t)
+			out := logLines(t, tt.log...)
+			fake.On("log").Returns(out)
+
+			nodes, err := task.Parse(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var others []*task.Task
+			for _, id := range tt.parents {
+				others = append(others, task.NewGraph(nodes).Get(id))
+			}
+
+			if err := linearizeDoneTask("d", others); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.Commands("rebase"); !slices.Equal(got, tt.want) {
+				t.Errorf("rebases:\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearizeDoneTaskRollsBack(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log", "--no-graph", "-T", "id.short()").Returns("op1\n")
+	fake.On("op", "log").Returns("op2 rebase\nop1 snapshot\n")
+	fake.On("rebase", "-s", "t1").Fails("Error: conflict")
+	fake.On("rebase")
+	fake.On("op", "restore")
+	prevClient := client
+	client = fake.Client()
+	t.Cleanup(func() { client = prevClient })
+
+	others := []*task.Task{{ChangeID: "t1", Flag: "wip"}}
+	if err := linearizeDoneTask("d", others); err == nil {
+		t.Fatal("expected error from failed rebase")
+	}
+	calls := fake.Calls()
+	if last := calls[len(calls)-1].String(); last != "op restore op1" {
+		t.Errorf("last call = %q, want op restore op1", last)
+	}
+}
@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/mcp"
+	"jjtask/internal/plan"
+	"jjtask/internal/task"
+)
+
+const (
+	mcpDAGURI    = "jjtask://dag"
+	mcpTaskURI   = "jjtask://task/"
+	mcpTaskMIME  = "text/markdown"
+	mcpDAGMIME   = "application/json"
+	mcpTaskUsage = "Task revision (change ID or revset)"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve task tools and resources over MCP (stdio)",
+	Long: `Run a Model Context Protocol server on stdin/stdout so MCP clients
+can work with tasks through structured JSON instead of parsing CLI output.
+
+Tools: find, show_desc, create, parallel, wip, done, flag, drop. Each runs
+the same logic as the command of the same name; mutating tools return the
+same result as the command's --format json.
+
+Resources:
+  jjtask://dag                 pending task DAG as a JSON plan
+  jjtask://task/{change_id}    task description (spec) as markdown
+
+jj output is written to stderr, which MCP clients show in their logs.
+
+Example client configuration:
+  {"mcpServers": {"jjtask": {"command": "jjtask", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The protocol owns stdin and stdout; send command and jj output to
+		// stderr and keep jj off the request stream
+		stdout, prevClient := os.Stdout, client
+		os.Stdout = os.Stderr
+		client = client.WithOutput(os.Stderr, os.Stderr)
+		defer func() { os.Stdout, client = stdout, prevClient }()
+		return mcpServer(cmd).Serve(os.Stdin, stdout)
+	},
+}
+
+func mcpServer(cmd *cobra.Command) *mcp.Server {
+	return &mcp.Server{
+		Name:    "jjtask",
+		Version: Version,
+		Tools: []mcp.Tool{
+			{
+				Name:        "find",
+				Description: "List tasks by status (pending, todo, wip, done, blocked, standby, untested, draft, review, ready, all) or revset",
+				InputSchema: mcpSchema(map[string]any{
+					"status": mcpString("Status filter (default pending)"),
+					"revset": mcpString("Custom revset, intersected with tasks()"),
+				}),
+				Handler: mcpFind,
+			},
+			{
+				Name:        "show_desc",
+				Description: "Get the full description of a revision",
+				InputSchema: mcpSchema(map[string]any{
+					"rev": mcpString("Revision (default @)"),
+				}),
+				Handler: mcpShowDesc,
+			},
+			{
+				Name:        "create",
+				Description: "Create a task as a child of parent (default @)",
+				InputSchema: mcpSchema(map[string]any{
+					"title":       mcpString("Task title"),
+					"description": mcpString("Task spec"),
+					"parent":      mcpString("Parent revision (default @)"),
+					"draft":       map[string]any{"type": "boolean", "description": "Create as draft"},
+				}, "title"),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpCreate(cmd, args)
+				},
+			},
+			{
+				Name:        "parallel",
+				Description: "Create sibling tasks under parent (default @)",
+				InputSchema: mcpSchema(map[string]any{
+					"titles": mcpStrings("Task titles"),
+					"parent": mcpString("Parent revision (default @)"),
+					"draft":  map[string]any{"type": "boolean", "description": "Create as drafts"},
+				}, "titles"),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpParallel(cmd, args)
+				},
+			},
+			{
+				Name:        "wip",
+				Description: "Mark tasks as WIP and add them to the @ merge",
+				InputSchema: mcpSchema(map[string]any{
+					"tasks": mcpStrings(mcpTaskUsage + "s (default @)"),
+				}),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpMutate(cmd, "wip", args, startTasks)
+				},
+			},
+			{
+				Name:        "done",
+				Description: "Mark tasks done and linearize them into @'s ancestry",
+				InputSchema: mcpSchema(map[string]any{
+					"tasks":     mcpStrings(mcpTaskUsage + "s (default @)"),
+					"force":     map[string]any{"type": "boolean", "description": "Mark done despite unchecked checklist items"},
+					"no_verify": map[string]any{"type": "boolean", "description": "Skip verification commands"},
+				}),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpDone(cmd, args)
+				},
+			},
+			{
+				Name:        "flag",
+				Description: "Set a task's status flag (" + strings.Join(validFlags, ", ") + ")",
+				InputSchema: mcpSchema(map[string]any{
+					"task":   mcpString(mcpTaskUsage + " (default @)"),
+					"status": map[string]any{"type": "string", "enum": validFlags},
+					"force":  map[string]any{"type": "boolean", "description": "Allow changes not listed in [statuses.transitions]"},
+				}, "status"),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpFlag(cmd, args)
+				},
+			},
+			{
+				Name:        "drop",
+				Description: "Remove tasks from the @ merge, marking them standby",
+				InputSchema: mcpSchema(map[string]any{
+					"tasks":   mcpStrings(mcpTaskUsage + "s"),
+					"abandon": map[string]any{"type": "boolean", "description": "Abandon the tasks entirely"},
+				}, "tasks"),
+				Handler: func(args json.RawMessage) (any, error) {
+					return mcpDrop(cmd, args)
+				},
+			},
+		},
+		Templates: []mcp.ResourceTemplate{{
+			URITemplate: mcpTaskURI + "{change_id}",
+			Name:        "task",
+			Description: "Task description (spec)",
+			MIMEType:    mcpTaskMIME,
+		}},
+		ListResources: mcpListResources,
+		ReadResource:  mcpReadResource,
+		// Other clients and jj itself change the repo between requests
+		Refresh: func() { graph = nil },
+	}
+}
+
+func mcpSchema(props map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func mcpString(desc string) map[string]any {
+	return map[string]any{"type": "string", "description": desc}
+}
+
+func mcpStrings(desc string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": desc}
+}
+
+func mcpFind(args json.RawMessage) (any, error) {
+	var p struct {
+		Status string `json:"status"`
+		Revset string `json:"revset"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	revset := fmt.Sprintf("(%s) & tasks()", p.Revset)
+	if p.Revset == "" {
+		r, err := statusRevset(p.Status)
+		if err != nil {
+			return nil, err
+		}
+		revset = r
+	}
+
+	tasks, err := task.Load(client, revset)
+	if err != nil {
+		return nil, err
+	}
+	g, err := taskGraph()
+	if err != nil {
+		return nil, fmt.Errorf("loading tasks: %w", err)
+	}
+	output := FindOutput{Tasks: []TaskItem{}}
+	for _, t := range tasks {
+		if p.Status == "ready" && p.Revset == "" && !g.IsReady(t.ChangeID) {
+			continue
+		}
+		output.Tasks = append(output.Tasks, newTaskItem(t))
+	}
+	output.Count = len(output.Tasks)
+	return output, nil
+}
+
+func mcpShowDesc(args json.RawMessage) (any, error) {
+	var p struct {
+		Rev string `json:"rev"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if p.Rev == "" {
+		p.Rev = "@"
+	}
+	return showDesc(p.Rev)
+}
+
+func mcpCreate(cmd *cobra.Command, args json.RawMessage) (any, error) {
+	var p struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Parent      string `json:"parent"`
+		Draft       bool   `json:"draft"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if p.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if p.Parent == "" {
+		p.Parent = "@"
+	}
+	return mcpResult(cmd, "create", func() error {
+		return withFlagValue(&createDraft, p.Draft, func() error {
+			return runCreate(cmd, []string{p.Parent, p.Title, p.Description})
+		})
+	})
+}
+
+func mcpParallel(cmd *cobra.Command, args json.RawMessage) (any, error) {
+	var p struct {
+		Titles []string `json:"titles"`
+		Parent string   `json:"parent"`
+		Draft  bool     `json:"draft"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Titles) == 0 {
+		return nil, fmt.Errorf("titles is required")
+	}
+	if p.Parent == "" {
+		p.Parent = "@"
+	}
+	return mcpResult(cmd, "parallel", func() error {
+		flag := "todo"
+		if p.Draft {
+			flag = "draft"
+		}
+		return createParallel(cmd, p.Parent, flag, p.Titles)
+	})
+}
+
+func mcpDone(cmd *cobra.Command, args json.RawMessage) (any, error) {
+	var p struct {
+		Force    bool `json:"force"`
+		NoVerify bool `json:"no_verify"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	return mcpMutate(cmd, "done", args, func(revs []string) error {
+		return withFlagValue(&doneForce, p.Force, func() error {
+			return withFlagValue(&doneNoVerify, p.NoVerify, func() error {
+				if err := checkDone(cmd, revs); err != nil {
+					return err
+				}
+				for _, rev := range revs {
+					if _, _, err := markDone(cmd, rev); err != nil {
+						return fmt.Errorf("failed to mark %s done: %w", rev, err)
+					}
+				}
+				return nil
+			})
+		})
+	})
+}
+
+func mcpFlag(cmd *cobra.Command, args json.RawMessage) (any, error) {
+	var p struct {
+		Task   string `json:"task"`
+		Status string `json:"status"`
+		Force  bool   `json:"force"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(validFlags, p.Status) {
+		return nil, fmt.Errorf("invalid flag %q, must be one of: %s", p.Status, strings.Join(validFlags, ", "))
+	}
+	if p.Task == "" {
+		p.Task = "@"
+	}
+	tasks, _ := json.Marshal(map[string][]string{"tasks": {p.Task}})
+	return mcpMutate(cmd, "flag", tasks, func(revs []string) error {
+		if !p.Force {
+			if err := checkTransition(revs[0], p.Status); err != nil {
+				return err
+			}
+		}
+		return setTaskFlag(revs[0], p.Status)
+	})
+}
+
+func mcpDrop(cmd *cobra.Command, args json.RawMessage) (any, error) {
+	var p struct {
+		Abandon bool `json:"abandon"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	return mcpMutate(cmd, "drop", args, func(revs []string) error {
+		return withFlagValue(&dropAbandon, p.Abandon, func() error {
+			for _, rev := range revs {
+				if err := dropTask(cmd, rev); err != nil {
+					return fmt.Errorf("failed to drop %s: %w", rev, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// mcpMutate runs fn on the "tasks" argument (default @)
+func mcpMutate(cmd *cobra.Command, name string, args json.RawMessage, fn func(revs []string) error) (any, error) {
+	var p struct {
+		Tasks []string `json:"tasks"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Tasks) == 0 {
+		p.Tasks = []string{"@"}
+	}
+	return mcpResult(cmd, name, func() error { return fn(p.Tasks) })
+}
+
+// mcpResult runs the named mutating command and reports what changed
+func mcpResult(cmd *cobra.Command, name string, fn func() error) (any, error) {
+	output, err := collectResult(cmd, fn)
+	if err != nil {
+		return nil, err
+	}
+	output.Command = "jjtask " + name
+	return output, nil
+}
+
+// withFlagValue sets a command's flag variable for the duration of fn
+func withFlagValue[T any](flag *T, value T, fn func() error) error {
+	prev := *flag
+	*flag = value
+	defer func() { *flag = prev }()
+	return fn()
+}
+
+func mcpListResources() ([]mcp.Resource, error) {
+	g, err := taskGraph()
+	if err != nil {
+		return nil, fmt.Errorf("loading tasks: %w", err)
+	}
+	resources := []mcp.Resource{{
+		URI:         mcpDAGURI,
+		Name:        "dag",
+		Description: "Pending task DAG as a plan (see 'jjtask plan export')",
+		MIMEType:    mcpDAGMIME,
+	}}
+	for _, t := range g.Pending() {
+		resources = append(resources, mcp.Resource{
+			URI:      mcpTaskURI + t.ChangeID,
+			Name:     fmt.Sprintf("[task:%s] %s", t.Flag, t.Title),
+			MIMEType: mcpTaskMIME,
+		})
+	}
+	return resources, nil
+}
+
+func mcpReadResource(uri string) (mcp.ResourceContents, error) {
+	g, err := taskGraph()
+	if err != nil {
+		return mcp.ResourceContents{}, fmt.Errorf("loading tasks: %w", err)
+	}
+	if uri == mcpDAGURI {
+		data, err := plan.Marshal(exportPlan(g, false), plan.FormatJSON)
+		if err != nil {
+			return mcp.ResourceContents{}, err
+		}
+		return mcp.ResourceContents{URI: uri, MIMEType: mcpDAGMIME, Text: string(data)}, nil
+	}
+	if id, ok := strings.CutPrefix(uri, mcpTaskURI); ok {
+		t, err := g.Resolve(id)
+		if err != nil {
+			return mcp.ResourceContents{}, err
+		}
+		return mcp.ResourceContents{URI: uri, MIMEType: mcpTaskMIME, Text: t.Description}, nil
+	}
+	return mcp.ResourceContents{}, fmt.Errorf("unknown resource %q", uri)
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
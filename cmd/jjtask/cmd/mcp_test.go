@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mcpCall sends one tools/call request and decodes its structured result
+func mcpCall(t *testing.T, tool, args string) map[string]any {
+	t.Helper()
+	req := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":` + args + `}}`
+	var out strings.Builder
+	if err := mcpServer(mcpCmd).Serve(strings.NewReader(req), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &resp); err != nil {
+		t.Fatalf("decoding %q: %v", out.String(), err)
+	}
+	return resp.Result
+}
+
+func TestMCPFlag(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:todo] X", "base: Base")).Once()
+	fake.On("describe")
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:review] X", "base: Base"))
+
+	result := mcpCall(t, "flag", `{"task":"x","status":"review"}`)
+	if result["isError"] != false {
+		t.Fatalf("flag failed: %v", result["content"])
+	}
+	tasks := result["structuredContent"].(map[string]any)["tasks"].([]any)
+	if len(tasks) != 1 || tasks[0].(map[string]any)["new_flag"] != "review" {
+		t.Errorf("tasks = %v, want x flagged review", tasks)
+	}
+	if got := fake.Commands("describe"); len(got) != 1 {
+		t.Errorf("describe calls = %v, want 1", got)
+	}
+}
+
+func TestMCPFlagRejectsUnknownStatus(t *testing.T) {
+	useFake(t)
+	result := mcpCall(t, "flag", `{"task":"x","status":"later"}`)
+	text := result["content"].([]any)[0].(map[string]any)["text"].(string)
+	if result["isError"] != true || !strings.Contains(text, "invalid flag") {
+		t.Errorf("result = %v, want invalid flag error", result)
+	}
+}
+
+func TestMCPParallelReturnsCreatedTasks(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:wip] X", "base: Base")).Once()
+	fake.On("new")
+	fake.On("log").Returns(logLines(t,
+		"at x: work",
+		"b x: [task:todo] B",
+		"a x: [task:todo] A",
+		"x base: [task:wip] X",
+		"base: Base",
+	))
+
+	result := mcpCall(t, "parallel", `{"parent":"x","titles":["A","B"]}`)
+	var ids []string
+	for _, item := range result["structuredContent"].(map[string]any)["tasks"].([]any) {
+		ids = append(ids, item.(map[string]any)["change_id"].(string))
+	}
+	if !slices.Equal(ids, []string{"a", "b"}) {
+		t.Errorf("created = %v, want [a b]", ids)
+	}
+	want := []string{"new --no-edit x -m [task:todo] A", "new --no-edit x -m [task:todo] B"}
+	if got := fake.Commands("new"); !slices.Equal(got, want) {
+		t.Errorf("commands = %v, want %v", got, want)
+	}
+}
+
+func TestMCPReadTaskResource(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:todo] X\n\nSpec", "base: Base"))
+
+	contents, err := mcpReadResource(mcpTaskURI + "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents.Text != "[task:todo] X\n\nSpec" || contents.MIMEType != mcpTaskMIME {
+		t.Errorf("contents = %+v", contents)
+	}
+	if _, err := mcpReadResource("jjtask://nope"); err == nil {
+		t.Error("unknown URI: want error")
+	}
+}
+
+func TestMCPReloadsBetweenRequests(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:todo] X", "base: Base")).Once()
+	// Changed by another client after the first request
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:wip] X", "base: Base"))
+
+	read := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"` + mcpTaskURI + `x"}}`
+	var out strings.Builder
+	if err := mcpServer(mcpCmd).Serve(strings.NewReader(read+"\n"+read), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "[task:wip] X"); got != 1 {
+		t.Errorf("responses = %s, want the second read to see the wip flag", out.String())
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/task"
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Read and edit task metadata trailers",
+	Long: `Read and edit "Key: value" trailers at the end of a task description.
+
+Known keys:
+  Priority   critical, high, medium, low
+  Assignee   person or agent responsible for the task
+  Labels     comma-separated labels
+  Due        due date (YYYY-MM-DD)
+  Estimate   effort estimate (e.g. 30m, 2h, 1.5d, 1w)
+
+Other keys must be listed under [metadata] keys in .jjtask.toml, so
+they are read back as trailers rather than as prose. Keys are
+case-insensitive. Depends-On, Task-Key and Verify shape how tasks are
+ordered and finished, so meta leaves them alone: manage them with
+'jjtask depend' and 'jjtask plan'.
+
+Examples:
+  jjtask meta set xyz priority high
+  jjtask meta set xyz labels backend,db
+  jjtask meta get xyz
+  jjtask meta get xyz assignee
+  jjtask meta unset xyz due`,
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:   "set <task> <key> <value>",
+	Short: "Set a metadata trailer",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rev, key := args[0], task.CanonicalKey(args[1])
+		if err := checkStructuralKey(key); err != nil {
+			return err
+		}
+		if !slices.Contains(task.KnownKeys, key) {
+			return fmt.Errorf("unknown key %q, add it to [metadata] keys in .jjtask.toml", key)
+		}
+		value, err := task.NormalizeTrailer(key, args[2])
+		if err != nil {
+			return err
+		}
+
+		t, err := resolveTask(rev)
+		if err != nil {
+			return err
+		}
+		if err := client.SetDescription(t.ID, task.SetTrailer(t.Description, key, value)); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		return nil
+	},
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:   "get <task> [key]",
+	Short: "Print metadata trailers",
+	Long: `Print all trailers of a task as "Key: value" lines, or only the
+value of one key.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := resolveTask(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			for _, tr := range t.Trailers {
+				fmt.Printf("%s: %s\n", tr.Key, tr.Value)
+			}
+			return nil
+		}
+
+		key := task.CanonicalKey(args[1])
+		values := t.Trailers.All(key)
+		if len(values) == 0 {
+			return fmt.Errorf("%s has no %s", t.ChangeID, key)
+		}
+		fmt.Println(strings.Join(values, "\n"))
+		return nil
+	},
+}
+
+var metaUnsetCmd = &cobra.Command{
+	Use:   "unset <task> <key>",
+	Short: "Remove a metadata trailer",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rev, key := args[0], task.CanonicalKey(args[1])
+		if err := checkStructuralKey(key); err != nil {
+			return err
+		}
+
+		t, err := resolveTask(rev)
+		if err != nil {
+			return err
+		}
+		desc := task.UnsetTrailer(t.Description, key)
+		if desc == t.Description {
+			return nil
+		}
+		if err := client.SetDescription(t.ID, desc); err != nil {
+			return fmt.Errorf("failed to unset %s: %w", key, err)
+		}
+		return nil
+	},
+}
+
+// structuralKeys maps the trailers other commands own to the way to edit them
+var structuralKeys = map[string]string{
+	task.KeyDependsOn: "use 'jjtask depend add/rm'",
+	task.KeyTaskKey:   "it is written by 'jjtask plan apply'",
+	task.KeyVerify:    "add it to the task description, or to a plan's trailers with 'jjtask plan apply'",
+}
+
+// checkStructuralKey refuses keys meta must not edit, like planDescription
+// refuses them in a plan's trailers
+func checkStructuralKey(key string) error {
+	if hint, ok := structuralKeys[key]; ok {
+		return fmt.Errorf("%s is not plain metadata, %s", key, hint)
+	}
+	return nil
+}
+
+// applyMetadataKeys registers the custom trailer keys from [metadata]
+func applyMetadataKeys() error {
+	cfg, err := config.GetMetadataConfig()
+	if err != nil {
+		return err
+	}
+	for _, k := range cfg.Keys {
+		if key := task.CanonicalKey(k); key != "" && !slices.Contains(task.KnownKeys, key) {
+			task.KnownKeys = append(task.KnownKeys, key)
+		}
+	}
+	return nil
+}
+
+// resolveTask looks up rev in the task graph and requires it to be a task
+func resolveTask(rev string) (*task.Task, error) {
+	g, err := taskGraph()
+	if err != nil {
+		return nil, fmt.Errorf("loading tasks: %w", err)
+	}
+	t, err := g.Resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsTask() {
+		return nil, fmt.Errorf("%s is not a task", t.ChangeID)
+	}
+	return t, nil
+}
+
+// completeMetaKey completes trailer keys after the task, then priority values
+func completeMetaKey(_cmd *cobra.Command, args []string, _toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 1:
+		keys := make([]string, len(task.KnownKeys))
+		for i, k := range task.KnownKeys {
+			keys[i] = strings.ToLower(k)
+		}
+		return keys, cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		if task.CanonicalKey(args[1]) == task.KeyPriority {
+			return task.Priorities, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	for _, c := range []*cobra.Command{metaSetCmd, metaGetCmd, metaUnsetCmd} {
+		c.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeTaskRevision(cmd, args, toComplete)
+			}
+			return completeMetaKey(cmd, args, toComplete)
+		}
+		metaCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(metaCmd)
+}
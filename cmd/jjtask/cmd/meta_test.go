@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetaRefusesStructuralKeys(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:todo] X\n\nDepends-On: base", "base: Base"))
+	fake.On("describe")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"set", "x", "depends-on", "base"}, "jjtask depend"},
+		{[]string{"unset", "x", "Depends-On"}, "jjtask depend"},
+		{[]string{"set", "x", "task-key", "k"}, "jjtask plan"},
+		{[]string{"set", "x", "verify", "true"}, "jjtask plan"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			sub, args, err := metaCmd.Find(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sub.RunE(sub, args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want a pointer to %s", err, tt.want)
+			}
+		})
+	}
+	if calls := fake.Commands("describe"); len(calls) != 0 {
+		t.Errorf("described %v, want no changes", calls)
+	}
+}
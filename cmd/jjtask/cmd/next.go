@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var (
+	nextStart  bool
+	nextFormat string
+)
+
+// NextItem is a ready task with its full description
+type NextItem struct {
+	TaskItem
+	Description string `json:"description"`
+}
+
+type NextOutput struct {
+	Tasks   []NextItem    `json:"tasks"`
+	Count   int           `json:"count"`
+	Started string        `json:"started,omitempty"`
+	Result  *ResultOutput `json:"result,omitempty"` // what --start changed
+}
+
+var nextCmd = &cobra.Command{
+	Use:   "next [--start]",
+	Short: "Show the next ready task",
+	Long: `List ready tasks in the order they should be picked up and print the
+full spec of the first one.
+
+Tasks that can start on top of @'s ancestry come first, then tasks with
+fewer pending ancestors, then the oldest. With --start, the first task is
+marked WIP and added to @ like 'jjtask wip'.
+
+Examples:
+  jjtask next                  # Show ready tasks and the next spec
+  jjtask next --start          # Start working on the next task
+  jjtask next --format json    # Machine-readable list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(nextFormat); err != nil {
+			return err
+		}
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		ready := g.NextReady()
+
+		var started string
+		var result *ResultOutput
+		if nextStart && len(ready) > 0 {
+			started = ready[0].ChangeID
+			start := func() error { return startTasks([]string{started}) }
+			if nextFormat == "json" {
+				// Keep wip's messages and jj's output off the JSON
+				output, err := collectResult(cmd, start)
+				if err != nil {
+					return err
+				}
+				output.Command = cmd.CommandPath()
+				result = &output
+			} else if err := start(); err != nil {
+				return err
+			}
+			// Show the task as it is now
+			if t, err := g.Resolve(started); err == nil {
+				ready[0] = t
+			}
+		}
+
+		if nextFormat == "json" {
+			output := NextOutput{Tasks: []NextItem{}, Started: started, Result: result}
+			for _, t := range ready {
+				output.Tasks = append(output.Tasks, NextItem{TaskItem: newTaskItem(t), Description: t.Description})
+			}
+			output.Count = len(output.Tasks)
+
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(output)
+		}
+
+		if len(ready) == 0 {
+			fmt.Println("No ready tasks")
+			return nil
+		}
+		printNext(ready, started)
+		return nil
+	},
+}
+
+// printNext lists the ready tasks, then the full description of the first
+func printNext(ready []*task.Task, started string) {
+	for i, t := range ready {
+		marker := " "
+		if i == 0 {
+			marker = "→"
+		}
+		fmt.Printf("%s %s %s\n", marker, t.ChangeID, t.Title)
+	}
+	fmt.Println()
+
+	if started != "" {
+		fmt.Printf("Started %s\n\n", started)
+	}
+	desc := ready[0].Description
+	fmt.Print(desc)
+	if desc != "" && !strings.HasSuffix(desc, "\n") {
+		fmt.Println()
+	}
+}
+
+func init() {
+	nextCmd.Flags().BoolVar(&nextStart, "start", false, "Mark the next task WIP and add it to @")
+	nextCmd.Flags().StringVar(&nextFormat, "format", "text", "Output format: text or json")
+	rootCmd.AddCommand(nextCmd)
+}
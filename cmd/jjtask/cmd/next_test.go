@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNextStartJSON(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log", "-r", "@", "--no-graph", "-T", "change_id.shortest()").Returns("at")
+	fake.On("log", "-r", "parents(@)").Returns("base\n")
+	fake.On("log").Returns(logLines(t, "at base: work", "a base: [task:todo] A", "base: Base")).Once()
+	fake.On("describe").Returns("Working copy now at: at\n")
+	fake.On("log").Returns(logLines(t, "at base a: work", "a base: [task:wip] A", "base: Base"))
+	nextStart, nextFormat = true, "json"
+	t.Cleanup(func() { nextStart, nextFormat = false, "text" })
+
+	var err error
+	out := captureStdout(t, func() { err = nextCmd.RunE(nextCmd, nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output NextOutput
+	if err := json.Unmarshal([]byte(out), &output); err != nil {
+		t.Fatalf("decoding %q: %v", out, err)
+	}
+	if output.Started != "a" || output.Result == nil || len(output.Result.Tasks) != 1 || output.Result.Tasks[0].NewFlag != "wip" {
+		t.Errorf("output = %+v, want a started as wip", output)
+	}
+}
+
+func TestNextRejectsFormat(t *testing.T) {
+	nextFormat = "yaml"
+	t.Cleanup(func() { nextFormat = "text" })
+	if err := nextCmd.RunE(nextCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("err = %v, want invalid format", err)
+	}
+}
@@ -33,19 +33,25 @@ Examples:
 			flag = "draft"
 		}
 
-		for _, title := range titles {
-			message := fmt.Sprintf("[task:%s] %s", flag, title)
-			if err := client.Run("new", "--no-edit", parent, "-m", message); err != nil {
-				return fmt.Errorf("failed to create task %q: %w", title, err)
-			}
+		return createParallel(cmd, parent, flag, titles)
+	},
+}
+
+// createParallel creates one task per title as siblings under parent
+func createParallel(cmd *cobra.Command, parent, flag string, titles []string) error {
+	for _, title := range titles {
+		message := fmt.Sprintf("[task:%s] %s", flag, title)
+		if err := client.Run("new", "--no-edit", parent, "-m", message); err != nil {
+			return fmt.Errorf("failed to create task %q: %w", title, err)
 		}
+	}
 
-		fmt.Printf("Created %d parallel task branches from %s\n", len(titles), parent)
-		return nil
-	},
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Created %d parallel task branches from %s\n", len(titles), parent)
+	return nil
 }
 
 func init() {
 	parallelCmd.Flags().BoolVar(&parallelDraft, "draft", false, "Create with [task:draft] flag")
+	withResultFormat(parallelCmd)
 	rootCmd.AddCommand(parallelCmd)
 }
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/plan"
+	"jjtask/internal/task"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Create or export a task DAG from a plan file",
+	Long: `Work with declarative task plans in YAML, TOML or JSON.
+
+A plan lists tasks with a stable key, title, spec, optional flag, parents
+and trailers:
+
+  parent: "@"            # where tasks without parents go (default @)
+  tasks:
+    - key: schema
+      title: Add sessions table
+      spec: |
+        ## Acceptance criteria
+        - [ ] migration runs
+      trailers:
+        Priority: high
+    - key: api
+      title: Session endpoints
+      parents: [schema]  # plan keys or revisions
+      depends_on: [auth] # plan keys or revisions
+
+Keys are stored as "Task-Key" trailers, so applying a plan again updates
+the tasks it created instead of duplicating them.`,
+}
+
+var (
+	planExportAll    bool
+	planExportFormat string
+	planExportOutput string
+)
+
+var planApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Create or update the tasks in a plan file",
+	Long: `Create the tasks declared in a plan file, or update them if a task
+with the same Task-Key already exists. Prints each key with its change ID.
+
+Existing tasks keep their status unless the plan sets a flag, and are only
+moved when the plan lists parents for them. Reads YAML from stdin when
+<file> is "-". Everything is rolled back if any step fails.
+
+Examples:
+  jjtask plan apply plan.yaml
+  jjtask plan apply --dry-run plan.toml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := plan.Load(args[0])
+		if err != nil {
+			return err
+		}
+		results, err := applyPlan(p)
+		if err != nil {
+			return err
+		}
+		width := 0
+		for _, r := range results {
+			width = max(width, len(r.Key))
+		}
+		for _, r := range results {
+			fmt.Printf("%-*s  %s  %s\n", width, r.Key, r.ChangeID, r.Action)
+		}
+		return nil
+	},
+}
+
+var planExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write tasks as a plan file",
+	Long: `Write pending tasks (or all with --all) as a plan document that
+'jjtask plan apply' accepts, with titles, specs, flags, parents, Depends-On
+edges and trailers.
+
+Tasks without a Task-Key are keyed by change ID, which apply also matches,
+so an exported plan can be edited and re-applied in place.
+
+Examples:
+  jjtask plan export > plan.yaml
+  jjtask plan export --all -o archive.toml
+  jjtask plan export --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := planExportFormat
+		if format == "" {
+			format = plan.FormatYAML
+			if planExportOutput != "" {
+				f, err := plan.FormatFromPath(planExportOutput)
+				if err != nil {
+					return err
+				}
+				format = f
+			}
+		}
+
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		data, err := plan.Marshal(exportPlan(g, planExportAll), format)
+		if err != nil {
+			return err
+		}
+		if planExportOutput == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(planExportOutput, data, 0o644)
+	},
+}
+
+// exportPlan converts pending (or all) tasks in g to a plan, parents first
+func exportPlan(g *task.Graph, all bool) *plan.Plan {
+	tasks := g.Pending()
+	if all {
+		tasks = g.Tasks()
+	}
+
+	keys := make(map[string]string, len(tasks)) // change ID -> plan key
+	for _, t := range tasks {
+		keys[t.ChangeID] = t.Key
+		if t.Key == "" {
+			keys[t.ChangeID] = dependencyRef(t)
+		}
+	}
+	ref := func(t *task.Task) string {
+		if key, ok := keys[t.ChangeID]; ok {
+			return key
+		}
+		return dependencyRef(t)
+	}
+
+	p := &plan.Plan{Tasks: []plan.Task{}}
+	// jj log order lists children first
+	for _, t := range slices.Backward(tasks) {
+		pt := plan.Task{
+			Key:   keys[t.ChangeID],
+			Title: t.Title,
+			Flag:  t.Flag,
+			Spec:  t.Body,
+		}
+		for _, id := range t.Parents {
+			if parent := g.Get(id); parent != nil {
+				pt.Parents = append(pt.Parents, ref(parent))
+			} else {
+				pt.Parents = append(pt.Parents, id)
+			}
+		}
+		for _, dep := range t.DependsOn {
+			if d := g.Lookup(dep); d != nil {
+				pt.DependsOn = append(pt.DependsOn, ref(d))
+			} else {
+				pt.DependsOn = append(pt.DependsOn, dep)
+			}
+		}
+		var trailers task.Trailers
+		for _, tr := range t.Trailers {
+			if tr.Key != task.KeyDependsOn && tr.Key != task.KeyTaskKey {
+				trailers = append(trailers, tr)
+			}
+		}
+		pt.SetTrailers(trailers)
+		p.Tasks = append(p.Tasks, pt)
+	}
+	return p
+}
+
+// planResult records what apply did for one plan task
+type planResult struct {
+	Key      string
+	ChangeID string
+	Action   string // created, updated, moved, unchanged
+}
+
+// applyPlan creates or updates every task in p inside one transaction
+func applyPlan(p *plan.Plan) ([]planResult, error) {
+	ordered, err := p.Order()
+	if err != nil {
+		return nil, err
+	}
+	for _, pt := range ordered {
+		if pt.Flag != "" && !slices.Contains(validFlags, pt.Flag) {
+			return nil, fmt.Errorf("task %s: invalid flag %q, must be one of: %s", pt.Key, pt.Flag, strings.Join(validFlags, ", "))
+		}
+	}
+
+	g, err := taskGraph()
+	if err != nil {
+		return nil, fmt.Errorf("loading tasks: %w", err)
+	}
+	existing := make(map[string]*task.Task)
+	for _, t := range g.Tasks() {
+		if t.Key != "" {
+			existing[t.Key] = t
+		}
+	}
+	for _, pt := range ordered {
+		// Exported tasks without a Task-Key use their change ID as key
+		if t := g.Lookup(pt.Key); existing[pt.Key] == nil && t != nil && t.IsTask() && t.Key == "" {
+			existing[pt.Key] = t
+		}
+	}
+
+	defaultParent := p.Parent
+	if defaultParent == "" {
+		defaultParent = "@"
+	}
+
+	var results []planResult
+	err = client.Transaction(func() error {
+		applied := make(map[string]*task.Task) // plan key -> task
+
+		// resolve maps a plan key or revision to a graph task
+		resolve := func(ref string) (*task.Task, error) {
+			if t, ok := applied[ref]; ok {
+				return t, nil
+			}
+			return g.Resolve(ref)
+		}
+
+		for _, pt := range ordered {
+			var parents []string
+			for _, ref := range pt.Parents {
+				t, err := resolve(ref)
+				if err != nil {
+					return fmt.Errorf("task %s: parent %s: %w", pt.Key, ref, err)
+				}
+				parents = append(parents, t.ID)
+			}
+			var deps []string
+			for _, ref := range pt.DependsOn {
+				t, err := resolve(ref)
+				if err != nil {
+					return fmt.Errorf("task %s: dependency %s: %w", pt.Key, ref, err)
+				}
+				deps = append(deps, dependencyRef(t))
+			}
+
+			current := existing[pt.Key]
+			flag := pt.Flag
+			if flag == "" {
+				flag = "todo"
+				if current != nil {
+					flag = current.Flag
+				}
+			}
+			desc, err := planDescription(pt, flag, deps)
+			if err != nil {
+				return err
+			}
+
+			if current == nil {
+				t, err := createPlanTask(pt.Key, desc, parents, defaultParent)
+				if err != nil {
+					return fmt.Errorf("creating %s: %w", pt.Key, err)
+				}
+				applied[pt.Key] = t
+				results = append(results, planResult{pt.Key, t.ChangeID, "created"})
+				continue
+			}
+
+			applied[pt.Key] = current
+			action := "unchanged"
+			if strings.TrimSpace(current.Description) != strings.TrimSpace(desc) {
+				if err := client.SetDescription(current.ID, desc); err != nil {
+					return fmt.Errorf("updating %s: %w", pt.Key, err)
+				}
+				action = "updated"
+			}
+			if len(parents) > 0 && !sameParents(current.Parents, parents) {
+				args := []string{"rebase", "-s", current.ID}
+				for _, p := range parents {
+					args = append(args, "-o", p)
+				}
+				if err := client.Run(args...); err != nil {
+					return fmt.Errorf("moving %s: %w", pt.Key, err)
+				}
+				action = "moved"
+			}
+			results = append(results, planResult{pt.Key, current.ChangeID, action})
+		}
+		return nil
+	})
+	return results, err
+}
+
+// planDescription renders a plan task as a task description with its
+// trailers, Depends-On references and Task-Key
+func planDescription(pt plan.Task, flag string, deps []string) (string, error) {
+	planTrailers, err := pt.TrailerList()
+	if err != nil {
+		return "", fmt.Errorf("task %s: %w", pt.Key, err)
+	}
+	var trailers task.Trailers
+	for _, t := range planTrailers {
+		key := task.CanonicalKey(t.Key)
+		if key == task.KeyTaskKey || key == task.KeyDependsOn {
+			return "", fmt.Errorf("task %s: set %s with the plan's key/depends_on fields", pt.Key, key)
+		}
+		value, err := task.NormalizeTrailer(key, t.Value)
+		if err != nil {
+			return "", fmt.Errorf("task %s: %w", pt.Key, err)
+		}
+		trailers = append(trailers, task.Trailer{Key: key, Value: value})
+	}
+	for _, dep := range deps {
+		trailers = append(trailers, task.Trailer{Key: task.KeyDependsOn, Value: dep})
+	}
+	trailers = append(trailers, task.Trailer{Key: task.KeyTaskKey, Value: pt.Key})
+	return task.JoinTrailers(task.Format(flag, pt.Title, strings.TrimSpace(pt.Spec)), trailers), nil
+}
+
+// createPlanTask creates a task revision and returns it as loaded from jj
+func createPlanTask(key, desc string, parents []string, defaultParent string) (*task.Task, error) {
+	if len(parents) == 0 {
+		parents = []string{defaultParent}
+	}
+	args := append([]string{"new", "--no-edit"}, parents...)
+	if err := client.Run(append(args, "-m", desc)...); err != nil {
+		return nil, err
+	}
+	if client.DryRun {
+		// Nothing was created; later commands refer to the task by key
+		return &task.Task{ID: "<" + key + ">", ChangeID: "<" + key + ">"}, nil
+	}
+	// The substring also matches longer keys, so compare the parsed trailer
+	revset := fmt.Sprintf("heads(children(%s) & description(substring:%q))", parents[0], task.KeyTaskKey+": "+key)
+	candidates, err := task.Load(client, revset)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range candidates {
+		if t.Key == key {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("created task %s not found", key)
+}
+
+// sameParents reports whether the shortest change IDs in short name the
+// same revisions as the full change IDs in full
+func sameParents(short, full []string) bool {
+	return len(short) == len(full) && !slices.ContainsFunc(short, func(prefix string) bool {
+		return !slices.ContainsFunc(full, func(id string) bool { return strings.HasPrefix(id, prefix) })
+	})
+}
+
+func init() {
+	planExportCmd.Flags().BoolVar(&planExportAll, "all", false, "Include done tasks")
+	planExportCmd.Flags().StringVar(&planExportFormat, "format", "", "Output format: yaml, toml or json (default from --output, else yaml)")
+	planExportCmd.Flags().StringVarP(&planExportOutput, "output", "o", "", "Write to file instead of stdout")
+	planCmd.AddCommand(planApplyCmd, planExportCmd)
+	rootCmd.AddCommand(planCmd)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/plan"
+)
+
+const testPlan = `
+tasks:
+  - key: api
+    title: Endpoints
+    parents: [schema]
+    depends_on: [schema]
+  - key: schema
+    title: Schema
+    spec: Add tables
+    trailers:
+      priority: HIGH
+`
+
+const (
+	schemaDesc = "[task:todo] Schema\n\nAdd tables\n\nPriority: high\nTask-Key: schema\n"
+	apiDesc    = "[task:todo] Endpoints\n\nDepends-On: sc\nTask-Key: api\n"
+)
+
+func TestApplyPlanCreates(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at base: work", "base: Base")).Once()
+	fake.On("new")
+	fake.On("log").Returns(logLines(t, "sc at: "+schemaDesc)).Once()
+	fake.On("log").Returns(logLines(t, "ap sc: "+apiDesc)).Once()
+
+	p, err := plan.Parse([]byte(testPlan), plan.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := applyPlan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []planResult{{"schema", "sc", "created"}, {"api", "ap", "created"}}
+	if !slices.Equal(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	wantCmds := []string{
+		"new --no-edit @ -m " + schemaDesc,
+		"new --no-edit sc -m " + apiDesc,
+	}
+	if got := fake.Commands("new"); !slices.Equal(got, wantCmds) {
+		t.Errorf("commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(wantCmds, "\n"))
+	}
+}
+
+func TestApplyPlanDependsOnLaterTask(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at base: work", "base: Base")).Once()
+	fake.On("new")
+	fake.On("log").Returns(logLines(t, "au at: [task:todo] Auth\n\nTask-Key: auth\n")).Once()
+	fake.On("log").Returns(logLines(t, "ap at: [task:todo] Endpoints\n\nDepends-On: au\nTask-Key: api\n")).Once()
+
+	p, err := plan.Parse([]byte(`
+tasks:
+  - key: api
+    title: Endpoints
+    depends_on: [auth]
+  - key: auth
+    title: Auth
+`), plan.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := applyPlan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []planResult{{"auth", "au", "created"}, {"api", "ap", "created"}}
+	if !slices.Equal(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	if got := fake.Commands("new"); len(got) != 2 || !strings.Contains(got[1], "Depends-On: au") {
+		t.Errorf("new calls = %v, want api created depending on au", got)
+	}
+}
+
+func TestApplyPlanIsIdempotent(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t,
+		"at base: work",
+		"ap sc: [task:wip] Endpoints\n\nDepends-On: sc\nTask-Key: api\n",
+		"sc base: "+strings.Replace(schemaDesc, "Add tables", "Old spec", 1),
+		"base: Base",
+	))
+	fake.On("describe")
+
+	p, err := plan.Parse([]byte(testPlan), plan.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := applyPlan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The wip flag survives, the schema spec is updated, and nothing is created
+	want := []planResult{{"schema", "sc", "updated"}, {"api", "ap", "unchanged"}}
+	if !slices.Equal(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	if got := fake.Commands("new", "rebase"); len(got) != 0 {
+		t.Errorf("unexpected commands: %v", got)
+	}
+}
+
+func TestApplyPlanUsesFullIDs(t *testing.T) {
+	fake := useFake(t)
+	existing := logLines(t, "at base: work", "sc base: "+strings.Replace(schemaDesc, "Add tables", "Old spec", 1), "base: Base")
+	fake.On("log").Returns(strings.Replace(existing, `"id":"sc"`, `"id":"scfull"`, 1)).Once()
+	fake.On("describe")
+	fake.On("new")
+	// A task whose key merely starts with "api" is not the one just created
+	fake.On("log").Returns(logLines(t,
+		"ax sc: [task:todo] Other\n\nTask-Key: api-v2\n",
+		"ap sc: "+apiDesc,
+	)).Once()
+
+	p, err := plan.Parse([]byte(testPlan), plan.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := applyPlan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []planResult{{"schema", "sc", "updated"}, {"api", "ap", "created"}}
+	if !slices.Equal(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	wantCmds := []string{
+		"describe -r scfull --stdin",
+		"new --no-edit scfull -m " + strings.Replace(apiDesc, "Depends-On: sc", "Depends-On: scfull", 1),
+	}
+	if got := fake.Commands("describe", "new"); !slices.Equal(got, wantCmds) {
+		t.Errorf("commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(wantCmds, "\n"))
+	}
+}
+
+func TestExportPlanRoundTrips(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t,
+		"at ap: work",
+		"ap sc: [task:wip] Endpoints\n\nDepends-On: sc\nTask-Key: api\n",
+		"sc base: [task:todo] Schema\n\nAdd tables\n\nVerify: make test\nVerify: make lint\nTask-Key: schema\n",
+		"base: Base",
+	))
+
+	g, err := taskGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{plan.FormatYAML, plan.FormatTOML, plan.FormatJSON} {
+		data, err := plan.Marshal(exportPlan(g, false), format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := plan.Parse(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, data)
+		}
+		if api := p.Get("api"); api == nil || !slices.Equal(api.Parents, []string{"schema"}) || !slices.Equal(api.DependsOn, []string{"schema"}) {
+			t.Errorf("%s: api = %+v", format, api)
+		}
+
+		results, err := applyPlan(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range results {
+			if r.Action != "unchanged" {
+				t.Errorf("%s: re-applying export %s = %s\n%s", format, r.Key, r.Action, data)
+			}
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"jjtask/internal/config"
+	"jjtask/internal/task"
 	"jjtask/internal/workspace"
 )
 
@@ -116,7 +117,7 @@ Use --compact for minimal output (task counts only).`,
 		fmt.Println()
 		fmt.Println("## JJ TASK Quick Reference")
 		fmt.Println()
-		fmt.Println("Task flags: draft → todo → wip → done (also: blocked, standby, untested, review)")
+		fmt.Println(primeFlagsLine())
 		fmt.Println()
 
 		fmt.Println("### Revsets")
@@ -189,6 +190,28 @@ func printCurrentTasks() {
 func printTaskDAG() {
 	repos, workspaceRoot, _ := workspace.GetRepos()
 	PrintTasksWithRevset(repos, workspaceRoot, "tasks_pending() | @")
+	printExcludedTasks(repos, workspaceRoot)
+}
+
+// printExcludedTasks notes pending tasks the task scope leaves out, so an
+// agent knows to widen it
+func printExcludedTasks(repos []workspace.Repo, workspaceRoot string) {
+	if scope, err := config.GetScopeConfig(); err != nil || scope.All {
+		return
+	}
+	counts := make([]int, len(repos))
+	_ = forEachRepo(repos, workspaceRoot, func(r repoRun) error {
+		counts[r.Index], _ = excludedPending(r.Client)
+		return nil
+	})
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total > 0 {
+		fmt.Println()
+		fmt.Printf("Note: %d pending task(s) outside the task scope. List them with `jjtask find --scope all`, or set [scope] in .jjtask.toml.\n", total)
+	}
 }
 
 // printPreCompactContext outputs task verification when context is nearly full
@@ -203,15 +226,21 @@ func printPreCompactContext() error {
 	repos, workspaceRoot, _ := workspace.GetRepos()
 	hasWIP := false
 
-	for _, repo := range repos {
-		repoPath := workspace.ResolveRepoPath(repo, workspaceRoot)
-		out, err := client.Query("-R", repoPath, "log", "--no-graph", "-r", "tasks_wip()", "-T", "task_log_flat")
+	wip := make([]string, len(repos))
+	_ = forEachRepo(repos, workspaceRoot, func(r repoRun) error {
+		if len(repos) > 1 {
+			qualifyIDs(r.Client, r.Repo)
+		}
+		out, err := r.Client.Query("log", "--no-graph", "-r", "tasks_wip()", "-T", "task_log_flat")
 		if err == nil {
-			outStr := strings.TrimRight(out, "\n")
-			if outStr != "" {
-				hasWIP = true
-				fmt.Println(outStr)
-			}
+			wip[r.Index] = strings.TrimRight(out, "\n")
+		}
+		return nil
+	})
+	for _, outStr := range wip {
+		if outStr != "" {
+			hasWIP = true
+			fmt.Println(outStr)
 		}
 	}
 
@@ -236,32 +265,45 @@ func printPreCompactContext() error {
 	return nil
 }
 
+// primeFlagsLine summarizes the status vocabulary, including custom statuses
+func primeFlagsLine() string {
+	also := []string{"blocked", "standby", "untested", "review"}
+	for _, s := range customStatuses {
+		also = append(also, s.Name)
+	}
+	return "Task flags: draft → todo → wip → done (also: " + strings.Join(also, ", ") + ")"
+}
+
 // printCompactPrime outputs minimal task summary
 func printCompactPrime() error {
 	repos, workspaceRoot, _ := workspace.GetRepos()
 
-	var totalWIP, totalTodo, totalDraft int
-	for _, repo := range repos {
-		repoPath := workspace.ResolveRepoPath(repo, workspaceRoot)
+	perRepo := make([][]*task.Task, len(repos))
+	_ = forEachRepo(repos, workspaceRoot, func(r repoRun) error {
+		perRepo[r.Index], _ = task.Load(r.Client, "tasks_wip() | tasks_todo() | tasks_draft()")
+		return nil
+	})
 
-		count := func(revset string) int {
-			out, _ := client.Query("-R", repoPath, "log", "--no-graph", "-r", revset, "-T", "change_id.shortest() ++ \"\\n\"")
-			if out == "" {
-				return 0
+	var totalWIP, totalTodo, totalDraft int
+	for _, tasks := range perRepo {
+		for _, t := range tasks {
+			switch t.Flag {
+			case "wip":
+				totalWIP++
+			case "todo":
+				totalTodo++
+			case "draft":
+				totalDraft++
 			}
-			return len(strings.Split(strings.TrimSpace(out), "\n"))
 		}
-
-		totalWIP += count("tasks_wip()")
-		totalTodo += count("tasks_todo()")
-		totalDraft += count("tasks_draft()")
 	}
 
 	fmt.Println()
 	fmt.Println("## JJ TASK Quick Reference")
 	fmt.Println()
-	fmt.Println("Task flags: draft → todo → wip → done (also: blocked, standby, untested, review)")
+	fmt.Println(primeFlagsLine())
 	fmt.Printf("Current: %d wip, %d todo, %d draft\n", totalWIP, totalTodo, totalDraft)
+	printExcludedTasks(repos, workspaceRoot)
 	fmt.Println()
 	fmt.Println("```")
 	fmt.Println("jjtask find              # show task DAG")
@@ -280,4 +322,5 @@ func printCompactPrime() error {
 func init() {
 	rootCmd.AddCommand(primeCmd)
 	primeCmd.Flags().BoolVar(&primeCompact, "compact", false, "minimal output (task counts only)")
+	addRepoFlags(primeCmd)
 }
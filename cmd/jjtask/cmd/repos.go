@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+	"jjtask/internal/workspace"
+)
+
+var (
+	repoJobs    int           // --jobs: repos queried at once, 0 for [workspaces] jobs
+	repoTimeout time.Duration // --timeout: per-repo limit, 0 for [workspaces] timeout
+
+	// repoPrefix qualifies IDs a command prints, e.g. "api:" while running
+	// on a repo-qualified revision
+	repoPrefix string
+)
+
+// repoRun is one repo's share of a multi-repo command
+type repoRun struct {
+	Index  int
+	Repo   workspace.Repo
+	Path   string     // resolved repo path
+	Client *jj.Client // bound to Path, the per-repo timeout and Out
+	Out    io.Writer  // printed in repo order once earlier repos finish
+}
+
+// addRepoFlags adds --jobs and --timeout to a command that visits every repo
+func addRepoFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&repoJobs, "jobs", "j", 0, "Repos to query at once (default: [workspaces] jobs, or CPU count)")
+	cmd.Flags().DurationVar(&repoTimeout, "timeout", 0, "Give up on a repo after this long, e.g. 5s (default: [workspaces] timeout, or none)")
+}
+
+// repoLimits resolves the worker count and per-repo timeout from flags and
+// [workspaces]
+func repoLimits() (int, time.Duration, error) {
+	cfg, err := config.GetWorkspacesConfig()
+	if err != nil {
+		return 0, 0, err
+	}
+	jobs, timeout := repoJobs, repoTimeout
+	if jobs == 0 {
+		jobs = cfg.Jobs
+	}
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs < 0 {
+		return 0, 0, fmt.Errorf("--jobs must be positive, got %d", jobs)
+	}
+	if timeout == 0 && cfg.Timeout != "" {
+		if timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+			return 0, 0, fmt.Errorf("[workspaces] timeout: %w", err)
+		}
+	}
+	return jobs, timeout, nil
+}
+
+// forEachRepo runs fn for every repo on a bounded pool of workers. Each
+// repo's output is buffered and printed in repo order, so the result reads
+// the same as a sequential run. With one job, output streams directly.
+// It returns the first error from fn in repo order.
+func forEachRepo(repos []workspace.Repo, workspaceRoot string, fn func(r repoRun) error) error {
+	jobs, timeout, err := repoLimits()
+	if err != nil {
+		return err
+	}
+
+	run := func(r repoRun) error {
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			r.Client = r.Client.WithContext(ctx)
+		}
+		return fn(r)
+	}
+
+	if jobs == 1 || len(repos) == 1 {
+		var first error
+		for i, repo := range repos {
+			r := repoRun{Index: i, Repo: repo, Path: workspace.ResolveRepoPath(repo, workspaceRoot), Out: os.Stdout}
+			r.Client = client.ForRepo(r.Path)
+			if err := run(r); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	type result struct {
+		out, errOut bytes.Buffer
+		err         error
+		done        chan struct{}
+	}
+	results := make([]*result, len(repos))
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+	go func() {
+		slots := make(chan struct{}, jobs)
+		for i, repo := range repos {
+			slots <- struct{}{}
+			go func() {
+				defer func() { <-slots }()
+				res := results[i]
+				defer close(res.done)
+				r := repoRun{Index: i, Repo: repo, Path: workspace.ResolveRepoPath(repo, workspaceRoot), Out: &res.out}
+				r.Client = client.ForRepo(r.Path).WithOutput(&res.out, &res.errOut)
+				res.err = run(r)
+			}()
+		}
+	}()
+
+	var first error
+	for _, res := range results {
+		<-res.done
+		_, _ = os.Stdout.Write(res.out.Bytes())
+		_, _ = os.Stderr.Write(res.errOut.Bytes())
+		if res.err != nil && first == nil {
+			first = res.err
+		}
+	}
+	return first
+}
+
+// printRepoPlaceholder marks a repo section without output, saying so when
+// the repo timed out
+func printRepoPlaceholder(w io.Writer, placeholder string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		placeholder = "(timed out)"
+	}
+	if client.IsTTY {
+		_, _ = fmt.Fprintf(w, "~  \033[32m%s\033[0m\n", placeholder)
+	} else {
+		_, _ = fmt.Fprintf(w, "~  %s\n", placeholder)
+	}
+}
+
+// withRepoRefs lets cmd take repo-qualified revisions like "api:xqq" in its
+// arguments and in the given string flags. The prefix is stripped and the
+// command runs with the package client pointed at that repo; unqualified
+// revisions resolve in the same repo. Revisions from different repos are
+// refused, since each run works on one repo's @.
+func withRepoRefs(cmd *cobra.Command, flags ...*string) {
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		repos, workspaceRoot, err := workspace.GetRepos()
+		if err != nil {
+			return err
+		}
+		var target *workspace.Repo
+		strip := func(ref string) (string, error) {
+			repo, rev, ok := workspace.SplitRef(ref, repos)
+			if !ok {
+				return ref, nil
+			}
+			if target != nil && workspace.DisplayName(*target) != workspace.DisplayName(repo) {
+				return "", fmt.Errorf("revisions are in different repos (%s, %s), run jjtask once per repo",
+					workspace.DisplayName(*target), workspace.DisplayName(repo))
+			}
+			target = &repo
+			return rev, nil
+		}
+
+		args = slices.Clone(args)
+		for i := range args {
+			if args[i], err = strip(args[i]); err != nil {
+				return err
+			}
+		}
+		for _, f := range flags {
+			if *f, err = strip(*f); err != nil {
+				return err
+			}
+		}
+		if target == nil {
+			return run(cmd, args)
+		}
+
+		prevClient, prevGraph, prevPrefix := client, graph, repoPrefix
+		client = client.ForRepo(workspace.ResolveRepoPath(*target, workspaceRoot))
+		graph, repoPrefix = nil, workspace.DisplayName(*target)+":"
+		defer func() { client, graph, repoPrefix = prevClient, prevGraph, prevPrefix }()
+		return run(cmd, args)
+	}
+}
+
+// qualifyIDs makes jj print change IDs in task templates with the repo name,
+// so IDs copied from a multi-repo listing work as repo-qualified revisions
+func qualifyIDs(c *jj.Client, repo workspace.Repo) {
+	prefix := strconv.Quote(workspace.DisplayName(repo) + ":")
+	c.Globals.Config = append(slices.Clip(c.Globals.Config), "template-aliases.task_repo="+strconv.Quote(prefix))
+}
+
+// parseRepoFlags strips leading --jobs/-j and --timeout options from the
+// arguments of a command that does its own flag parsing
+func parseRepoFlags(args []string) ([]string, error) {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		if name != "--jobs" && name != "-j" && name != "--timeout" {
+			return args, nil
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s needs a value", name)
+			}
+			value, args = args[0], args[1:]
+		}
+		var err error
+		if name == "--timeout" {
+			repoTimeout, err = time.ParseDuration(value)
+		} else {
+			repoJobs, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/jj"
+	"jjtask/internal/workspace"
+)
+
+// repoExecutor answers every jj call with the repo it was run in. Repos
+// named "slow" block until their context is done; earlier repos answer
+// later, so a parallel run finishes out of order.
+type repoExecutor struct{}
+
+func (repoExecutor) Execute(inv jj.Invocation) error {
+	i := slices.Index(inv.Args, "-R")
+	repo := inv.Args[i+1]
+	if strings.HasSuffix(repo, "slow") {
+		<-inv.Context.Done()
+		return fmt.Errorf("jj %w", inv.Context.Err())
+	}
+	delay := map[string]time.Duration{"/ws/a": 30, "/ws/b": 20, "/ws/c": 10}[repo]
+	time.Sleep(delay * time.Millisecond)
+	_, _ = fmt.Fprintf(inv.Stdout, "%s\n", repo)
+	return nil
+}
+
+// useRepoClient points the package client at repoExecutor and resets the
+// repo flags
+func useRepoClient(t *testing.T, jobs int, timeout time.Duration) {
+	t.Helper()
+	useStatusConfig(t, "")
+	prevClient := client
+	client = jj.NewWithExecutor(repoExecutor{})
+	repoJobs, repoTimeout = jobs, timeout
+	t.Cleanup(func() {
+		client = prevClient
+		repoJobs, repoTimeout = 0, 0
+	})
+}
+
+// captureStdout returns what fn prints to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	os.Stdout = prev
+	_ = w.Close()
+	return <-done
+}
+
+func TestForEachRepoOrder(t *testing.T) {
+	repos := []workspace.Repo{{Path: "a"}, {Path: "b"}, {Path: "c"}}
+	for _, jobs := range []int{1, 3} {
+		useRepoClient(t, jobs, 0)
+		out := captureStdout(t, func() {
+			err := forEachRepo(repos, "/ws", func(r repoRun) error {
+				_, _ = fmt.Fprintf(r.Out, "=== %s ===\n", r.Repo.Path)
+				return r.Client.Run("log")
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+		want := "=== a ===\n/ws/a\n=== b ===\n/ws/b\n=== c ===\n/ws/c\n"
+		if out != want {
+			t.Errorf("jobs %d: output =\n%s\nwant\n%s", jobs, out, want)
+		}
+	}
+}
+
+func TestForEachRepoTimeout(t *testing.T) {
+	useRepoClient(t, 2, 20*time.Millisecond)
+	repos := []workspace.Repo{{Path: "slow"}, {Path: "c"}}
+	out := captureStdout(t, func() {
+		_ = forEachRepo(repos, "/ws", func(r repoRun) error {
+			if err := r.Client.Run("log"); err != nil {
+				printRepoPlaceholder(r.Out, "(no output)", err)
+			}
+			return nil
+		})
+	})
+	if want := "~  (timed out)\n/ws/c\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestParseRepoFlags(t *testing.T) {
+	useRepoClient(t, 0, 0)
+	args, err := parseRepoFlags([]string{"-j", "4", "--timeout=2s", "log", "--jobs", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(args, []string{"log", "--jobs", "1"}) || repoJobs != 4 || repoTimeout != 2*time.Second {
+		t.Errorf("args = %v, jobs = %d, timeout = %v", args, repoJobs, repoTimeout)
+	}
+	if _, err := parseRepoFlags([]string{"--timeout"}); err == nil {
+		t.Error("want error for missing value")
+	}
+	if _, err := parseRepoFlags([]string{"--jobs", "x", "log"}); err == nil {
+		t.Error("want error for invalid jobs")
+	}
+}
+
+func TestRepoLimitsConfig(t *testing.T) {
+	useRepoClient(t, 0, 0)
+	useStatusConfig(t, "[workspaces]\njobs = 3\ntimeout = \"4s\"\n")
+	jobs, timeout, err := repoLimits()
+	if err != nil || jobs != 3 || timeout != 4*time.Second {
+		t.Errorf("repoLimits = %d, %v, %v; want 3, 4s", jobs, timeout, err)
+	}
+	repoJobs = 1
+	if jobs, _, _ := repoLimits(); jobs != 1 {
+		t.Errorf("--jobs did not override config, got %d", jobs)
+	}
+}
+
+func TestSplitRef(t *testing.T) {
+	repos := []workspace.Repo{{Path: "api"}, {Path: "web", Name: "frontend"}}
+	tests := []struct {
+		ref, repo, rev string
+	}{
+		{"api:xqq", "api", "xqq"},
+		{"frontend:@-", "web", "@-"},
+		{"web:xqq", "", "web:xqq"},         // names win over paths
+		{"api::xqq", "", "api::xqq"},       // revset range
+		{"api: fix it", "", "api: fix it"}, // title
+		{"xqq", "", "xqq"},
+	}
+	for _, tt := range tests {
+		repo, rev, ok := workspace.SplitRef(tt.ref, repos)
+		if ok != (tt.repo != "") || repo.Path != tt.repo || rev != tt.rev {
+			t.Errorf("SplitRef(%q) = %q, %q, %v; want %q, %q", tt.ref, repo.Path, rev, ok, tt.repo, tt.rev)
+		}
+	}
+}
+
+func TestWithRepoRefs(t *testing.T) {
+	useRepoClient(t, 0, 0)
+	useStatusConfig(t, "[workspaces]\nrepos = [{path = \"api\"}, {path = \"web\"}]\n")
+	rev := "web:abc"
+
+	var gotRepo, gotRev, gotPrefix string
+	var gotArgs []string
+	cmd := &cobra.Command{RunE: func(cmd *cobra.Command, args []string) error {
+		gotRepo, gotRev, gotPrefix, gotArgs = client.Globals.Repository, rev, repoPrefix, args
+		return nil
+	}}
+	withRepoRefs(cmd, &rev)
+
+	if err := cmd.RunE(cmd, []string{"web:xqq", "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(gotRepo, "/web") || gotRev != "abc" || gotPrefix != "web:" || !slices.Equal(gotArgs, []string{"xqq", "done"}) {
+		t.Errorf("repo %q rev %q prefix %q args %v", gotRepo, gotRev, gotPrefix, gotArgs)
+	}
+	if client.Globals.Repository != "" || repoPrefix != "" {
+		t.Error("client not restored after the command")
+	}
+
+	if err := cmd.RunE(cmd, []string{"api:xqq", "web:abc"}); err == nil || !strings.Contains(err.Error(), "different repos") {
+		t.Errorf("err = %v, want refusal for revisions in two repos", err)
+	}
+}
+
+func TestCrossRepoLinks(t *testing.T) {
+	fake := useFake(t)
+	useStatusConfig(t, "[workspaces]\nrepos = [{path = \"api\"}, {path = \"web\"}]\n")
+	fake.On("log").Returns(logLines(t, "c b: [task:todo] C\n\nDepends-On: web:b", "d b: [task:todo] D")).Once()
+	fake.On("log").Returns(logLines(t, "b base: [task:wip] B"))
+
+	var out strings.Builder
+	printCrossRepoLinks(&out, client, workspace.Repo{Path: "api"}, "tasks_pending()")
+	if want := "Depends on other repos:\n  api:c → web:b [task:wip] B (blocked)\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	dep, err := resolveDependency("web:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dependencyRef(dep); got != "web:b" {
+		t.Errorf("dependencyRef = %s, want web:b", got)
+	}
+}
+
+// stdinExecutor records whether each jj call got the terminal's stdin
+type stdinExecutor struct {
+	mu       sync.Mutex
+	terminal []bool
+}
+
+func (e *stdinExecutor) Execute(inv jj.Invocation) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.terminal = append(e.terminal, inv.Stdin == os.Stdin)
+	return nil
+}
+
+func TestAllKeepsTerminal(t *testing.T) {
+	useRepoClient(t, 0, 0)
+	useStatusConfig(t, "[workspaces]\njobs = 4\nrepos = [{path = \"api\"}, {path = \"web\"}]\n")
+	exec := &stdinExecutor{}
+	client = jj.NewWithExecutor(exec)
+
+	captureStdout(t, func() {
+		if err := allCmd.RunE(allCmd, []string{"describe"}); err != nil {
+			t.Error(err)
+		}
+	})
+	if repoJobs != 1 || !slices.Equal(exec.terminal, []bool{true, true}) {
+		t.Errorf("jobs = %d, stdin per repo = %v; want 1 job with the terminal", repoJobs, exec.terminal)
+	}
+}
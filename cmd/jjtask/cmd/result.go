@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var resultFormat string
+
+// ChangedTask is a revision a command created, rewrote, moved or abandoned
+type ChangedTask struct {
+	ChangeID string   `json:"change_id"`
+	Title    string   `json:"title"`
+	Action   string   `json:"action"` // created, updated, moved, abandoned
+	OldFlag  string   `json:"old_flag,omitempty"`
+	NewFlag  string   `json:"new_flag,omitempty"`
+	Parents  []string `json:"parents,omitempty"`
+}
+
+// ResultOutput is the --format json result of a command that modifies tasks
+type ResultOutput struct {
+	Command            string        `json:"command"`
+	Tasks              []ChangedTask `json:"tasks"`
+	WorkingCopyParents []string      `json:"working_copy_parents"`
+	Warnings           []Warning     `json:"warnings"`
+	Orphans            []string      `json:"orphans"`
+	Operation          string        `json:"operation,omitempty"`
+}
+
+// withResultFormat adds --format to a mutating command. In json mode the
+// command's messages and jj's output go to stderr, and stdout gets a
+// ResultOutput built by comparing the task graph before and after.
+func withResultFormat(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&resultFormat, "format", "text", "Output format: text or json")
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(resultFormat); err != nil {
+			return err
+		}
+		if resultFormat == "json" {
+			return runWithResult(cmd, os.Stdout, func() error { return run(cmd, args) })
+		}
+		return run(cmd, args)
+	}
+}
+
+// checkFormat rejects a --format value other than text or json
+func checkFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format %q, must be text or json", format)
+	}
+	return nil
+}
+
+// runWithResult runs a command with its output moved to stderr and writes
+// the ResultOutput to w
+func runWithResult(cmd *cobra.Command, w io.Writer, run func() error) error {
+	output, err := collectResult(cmd, run)
+	if err != nil {
+		return err
+	}
+	output.Command = cmd.CommandPath()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(output)
+}
+
+// collectResult runs a mutating command, collecting its warnings instead of
+// printing them, and reports what changed
+func collectResult(cmd *cobra.Command, run func() error) (ResultOutput, error) {
+	g, err := taskGraph()
+	if err != nil {
+		return ResultOutput{}, fmt.Errorf("loading tasks: %w", err)
+	}
+	before := slices.Clone(g.Nodes())
+
+	reportedWarnings, quietWarnings = nil, true
+	defer func() { quietWarnings = false }()
+	err = redirectOutput(cmd, os.Stderr, run)
+	if err != nil {
+		for _, w := range reportedWarnings {
+			w.render(os.Stderr)
+		}
+		return ResultOutput{}, err
+	}
+
+	output, err := resultOutput(before)
+	if err != nil {
+		return ResultOutput{}, err
+	}
+	output.Warnings = append(output.Warnings, reportedWarnings...)
+	return output, nil
+}
+
+// redirectOutput runs fn with the command's and jj's output written to w:
+// cmd's writers and a copy of the client point at w, and jj gets no stdin.
+// The task graph follows the copy and is reloaded once fn returns.
+func redirectOutput(cmd *cobra.Command, w io.Writer, fn func() error) error {
+	prevClient, prevOut, prevErr := client, cmd.OutOrStdout(), cmd.ErrOrStderr()
+	client = client.WithOutput(w, w)
+	if graph != nil && graph.Refresh() == nil {
+		graph.SetClient(client)
+	} else {
+		graph = nil
+	}
+	cmd.SetOut(w)
+	cmd.SetErr(w)
+	defer func() {
+		client, graph = prevClient, nil
+		cmd.SetOut(prevOut)
+		cmd.SetErr(prevErr)
+	}()
+	return fn()
+}
+
+// resultOutput compares the revisions in before with the reloaded graph
+func resultOutput(before []*task.Task) (ResultOutput, error) {
+	output := ResultOutput{Tasks: []ChangedTask{}, WorkingCopyParents: []string{}, Warnings: []Warning{}, Orphans: []string{}}
+	g, err := taskGraph()
+	if err != nil {
+		return output, fmt.Errorf("loading tasks: %w", err)
+	}
+	if op, err := client.CurrentOperation(); err == nil {
+		output.Operation = op
+	}
+	at := g.WorkingCopy()
+	if at != nil {
+		output.WorkingCopyParents = append(output.WorkingCopyParents, at.Parents...)
+	}
+
+	// Match by full change ID; shortest prefixes grow as revisions are added
+	after := make(map[string]*task.Task)
+	for _, t := range g.Nodes() {
+		after[t.ID] = t
+	}
+
+	// Revisions that left the graph were either abandoned or stopped being
+	// tasks (finalize); look the latter up directly
+	var missing []string
+	for _, old := range before {
+		if after[old.ID] == nil && old.IsTask() {
+			missing = append(missing, "present("+old.ID+")")
+		}
+	}
+	if len(missing) > 0 {
+		found, err := task.Load(client, strings.Join(missing, " | "))
+		if err != nil {
+			return output, err
+		}
+		for _, t := range found {
+			after[t.ID] = t
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, old := range before {
+		seen[old.ID] = true
+		now := after[old.ID]
+		if !old.IsTask() && (now == nil || !now.IsTask()) {
+			continue
+		}
+		change := ChangedTask{ChangeID: old.ChangeID, Title: old.Title, OldFlag: old.Flag}
+		switch {
+		case now == nil:
+			change.Action = "abandoned"
+		case now.Description != old.Description:
+			change.Action = "updated"
+		case !sameChangeIDs(now.Parents, old.Parents):
+			change.Action = "moved"
+		default:
+			continue
+		}
+		if now != nil {
+			change.ChangeID, change.Title, change.NewFlag, change.Parents = now.ChangeID, now.Title, now.Flag, now.Parents
+			if !now.IsTask() {
+				change.Title = now.FirstLine()
+			}
+			if task.IsDone(now.Flag) && !task.IsDone(old.Flag) && at != nil && !g.IsAncestorOf(now.ChangeID, at.ChangeID) {
+				output.Orphans = append(output.Orphans, now.ChangeID)
+			}
+		}
+		output.Tasks = append(output.Tasks, change)
+	}
+	// jj log order lists children first
+	for _, t := range slices.Backward(g.Tasks()) {
+		if !seen[t.ID] {
+			output.Tasks = append(output.Tasks, ChangedTask{
+				ChangeID: t.ChangeID,
+				Title:    t.Title,
+				Action:   "created",
+				NewFlag:  t.Flag,
+				Parents:  t.Parents,
+			})
+		}
+	}
+	return output, nil
+}
+
+// sameChangeIDs compares shortest change ID lists taken at different times
+func sameChangeIDs(a, b []string) bool {
+	return slices.EqualFunc(a, b, func(x, y string) bool {
+		return strings.HasPrefix(x, y) || strings.HasPrefix(y, x)
+	})
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj/jjtest"
+)
+
+func TestRunWithResult(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t,
+		"at x y: work",
+		"c x: [task:todo] C",
+		"y base: [task:wip] Y",
+		"x base: [task:wip] X",
+		"base: Base",
+	)).Once()
+	fake.On("describe")
+	fake.On("log").Returns(logLines(t,
+		"at x y: work",
+		"c x: [task:todo] C",
+		"y base: [task:wip] Y",
+		"x base: [task:done] X",
+		"base: Base",
+	))
+
+	var out strings.Builder
+	err := runWithResult(flagCmd, &out, func() error {
+		checkPendingChildren(flagCmd, "x")
+		return setTaskFlag("x", "done")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result ResultOutput
+	if err := json.Unmarshal([]byte(out.String()), &result); err != nil {
+		t.Fatalf("decoding %q: %v", out.String(), err)
+	}
+
+	want := ChangedTask{ChangeID: "x", Title: "X", Action: "updated", OldFlag: "wip", NewFlag: "done", Parents: []string{"base"}}
+	if len(result.Tasks) != 1 || !reflect.DeepEqual(result.Tasks[0], want) {
+		t.Errorf("tasks = %+v, want [%+v]", result.Tasks, want)
+	}
+	if !slices.Equal(result.WorkingCopyParents, []string{"x", "y"}) {
+		t.Errorf("working_copy_parents = %v", result.WorkingCopyParents)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0].Code != WarnPendingChildren || !slices.Equal(result.Warnings[0].Revisions, []string{"x", "c"}) {
+		t.Errorf("warnings = %+v, want pending-children for x", result.Warnings)
+	}
+	if len(result.Orphans) != 0 {
+		t.Errorf("orphans = %v, x is in @'s ancestry", result.Orphans)
+	}
+}
+
+func TestCollectResultRestoresAfterPanic(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:wip] X", "base: Base"))
+	prevClient := client
+	cmd := &cobra.Command{}
+	var stdout strings.Builder
+	cmd.SetOut(&stdout)
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = collectResult(cmd, func() error { panic("boom") })
+	}()
+
+	if quietWarnings {
+		t.Error("quietWarnings still set after a panicking run")
+	}
+	if client != prevClient {
+		t.Error("client not restored after a panicking run")
+	}
+	if cmd.OutOrStdout() != &stdout {
+		t.Error("command output not restored after a panicking run")
+	}
+}
+
+func TestWarnPolicy(t *testing.T) {
+	empty := func() *Warning {
+		return &Warning{Code: WarnEmptyTask, Severity: SeverityWarning, Message: "Task is empty - no changes to mark done", body: []string{"If this is a planning-only task, this warning can be ignored."}}
+	}
+	tests := []struct {
+		name       string
+		config     string
+		strict     bool
+		wantOutput bool
+		wantErr    bool
+	}{
+		{name: "default", wantOutput: true},
+		{name: "ignored", config: "[warnings]\nignore = [\"empty-task\"]\n"},
+		{name: "escalated", config: "[warnings]\nerror = [\"empty-task\"]\n", wantOutput: true, wantErr: true},
+		{name: "strict", strict: true, wantOutput: true, wantErr: true},
+		{name: "ignore beats strict", config: "[warnings]\nignore = [\"empty-task\"]\n", strict: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".jjtask.toml"), []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Chdir(dir)
+			config.Reset()
+			t.Cleanup(config.Reset)
+			strict = tt.strict
+			t.Cleanup(func() { strict = false })
+
+			var stderr strings.Builder
+			cmd := &cobra.Command{}
+			cmd.SetErr(&stderr)
+			err := warn(cmd, empty())
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := ""
+			if tt.wantOutput {
+				want = "\n⚠️  Task is empty - no changes to mark done\nIf this is a planning-only task, this warning can be ignored.\n\n"
+			}
+			if stderr.String() != want {
+				t.Errorf("stderr = %q, want %q", stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestWarnRejectsUnknownCode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".jjtask.toml"), []byte("[warnings]\nerror = [\"pending-kids\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	if err := warn(&cobra.Command{}); err == nil || !strings.Contains(err.Error(), "pending-kids") {
+		t.Errorf("err = %v, want unknown code error", err)
+	}
+}
+
+func TestApplyFlagRollsBackEscalatedWarning(t *testing.T) {
+	useStatusConfig(t, "[warnings]\nerror = [\"working-copy-diff\"]\n")
+	fake := jjtest.New()
+	fake.On("op", "log", "--no-graph", "-T", "id.short()").Returns("op1\n")
+	fake.On("op", "log").Returns("op2 describe\nop1 snapshot\n")
+	fake.On("op", "restore")
+	// @ has uncommitted changes and is not the task
+	log := logLines(t, "at x: work", "x base: [task:todo] X", "base: Base")
+	fake.On("log").Returns(strings.Replace(log, `"empty":true`, `"empty":false`, 1))
+	fake.On("describe")
+	fake.On("diff").Returns("main.go | 2 +-\n1 file changed\n")
+	prevClient, prevGraph := client, graph
+	client, graph = fake.Client(), nil
+	t.Cleanup(func() { client, graph = prevClient, prevGraph })
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(&strings.Builder{})
+	if err := applyFlag(cmd, "x", "wip"); err == nil {
+		t.Fatal("want error from escalated working-copy-diff")
+	}
+	calls := fake.Calls()
+	if last := calls[len(calls)-1].String(); last != "op restore op1" {
+		t.Errorf("last call = %q, want op restore op1", last)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"jjtask/internal/jj"
+	"jjtask/internal/task"
 )
 
 var (
 	client  *jj.Client
 	globals jj.GlobalFlags
+	graph   *task.Graph
+	dryRun  bool
 )
 
 var Version = "dev"
@@ -18,11 +21,35 @@ var rootCmd = &cobra.Command{
 	Short:   "Task management for jj repositories",
 	Long:    "jjtask provides structured task management using jj revisions with [task:*] flags.",
 	Version: Version,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		client = jj.NewWithGlobals(globals)
+		client.DryRun = dryRun
+		if err := applyStatuses(client); err != nil {
+			return err
+		}
+		if err := applyScope(client); err != nil {
+			return err
+		}
+		if err := applyMetadataKeys(); err != nil {
+			return err
+		}
+		return applyRevsets(client)
 	},
 }
 
+// taskGraph returns the task graph for this invocation, loading it on first
+// use. The graph reloads itself after client mutations.
+func taskGraph() (*task.Graph, error) {
+	if graph == nil {
+		g, err := loadGraph(client)
+		if err != nil {
+			return nil, err
+		}
+		graph = g
+	}
+	return graph, graph.Refresh()
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -40,6 +67,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&globals.Quiet, "quiet", false, "Silence non-primary output")
 	rootCmd.PersistentFlags().BoolVar(&globals.NoPager, "no-pager", false, "Disable pager")
 
+	// jjtask flags
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the jj commands that would modify the repo instead of running them")
+
 	// Silence usage on error
 	rootCmd.SilenceUsage = true
 
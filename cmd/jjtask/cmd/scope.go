@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
+)
+
+// defaultScopeDepth is how many ancestors of @ tasks() searches from
+const defaultScopeDepth = 200
+
+// taskMarker matches every task revision, in or out of scope
+const taskMarker = `description(substring:"[task:")`
+
+// parseScope reads a --scope value: "all", a depth, or a base revset
+func parseScope(s string) config.ScopeConfig {
+	if s == "all" {
+		return config.ScopeConfig{All: true}
+	}
+	if depth, err := strconv.Atoi(s); err == nil {
+		return config.ScopeConfig{Depth: depth}
+	}
+	return config.ScopeConfig{Base: s}
+}
+
+// scopeWindow returns the revisions tasks() searches for scope
+func scopeWindow(scope config.ScopeConfig) (string, error) {
+	set := 0
+	for _, ok := range []bool{scope.Depth != 0, scope.Base != "", scope.All} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set > 1:
+		return "", fmt.Errorf("[scope] set only one of depth, base and all")
+	case scope.Depth < 0:
+		return "", fmt.Errorf("scope depth must be positive, got %d", scope.Depth)
+	case scope.All:
+		return "all()", nil
+	case scope.Base != "":
+		return "(" + scope.Base + ")::", nil
+	case scope.Depth != 0:
+		return fmt.Sprintf("ancestors(@, %d)::", scope.Depth), nil
+	}
+	return fmt.Sprintf("ancestors(@, %d)::", defaultScopeDepth), nil
+}
+
+// tasksRevset builds the tasks() alias for scope
+func tasksRevset(scope config.ScopeConfig) (string, error) {
+	window, err := scopeWindow(scope)
+	if err != nil {
+		return "", err
+	}
+	return window + " & " + taskMarker, nil
+}
+
+// applyScope passes the configured [scope] to jj through c
+func applyScope(c *jj.Client) error {
+	scope, err := config.GetScopeConfig()
+	if err != nil || scope == (config.ScopeConfig{}) {
+		return err
+	}
+	return setScope(c, scope)
+}
+
+// setScope overrides the tasks() alias for jj calls through c
+func setScope(c *jj.Client, scope config.ScopeConfig) error {
+	revset, err := tasksRevset(scope)
+	if err != nil {
+		return err
+	}
+	c.Globals.Config = append(c.Globals.Config, `revset-aliases."tasks()"=`+strconv.Quote(revset))
+	return nil
+}
+
+// excludedPending counts pending task revisions that tasks() does not see.
+// It only searches mutable() so prime does not scan the whole history;
+// pending tasks are rarely pushed.
+func excludedPending(c *jj.Client) (int, error) {
+	revset := "mutable() & " + taskMarker + " ~ tasks()"
+	for _, flag := range task.DoneFlags {
+		revset += fmt.Sprintf(` ~ description(substring:"[task:%s]")`, flag)
+	}
+	out, err := c.Query("log", "-r", revset, "--no-graph", "-T", `change_id.shortest() ++ "\n"`)
+	if err != nil {
+		return 0, err
+	}
+	return len(strings.Fields(out)), nil
+}
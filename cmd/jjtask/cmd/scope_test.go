@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+)
+
+func TestTasksRevset(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{"", `ancestors(@, 200):: & description(substring:"[task:")`},
+		{"1000", `ancestors(@, 1000):: & description(substring:"[task:")`},
+		{"all", `all() & description(substring:"[task:")`},
+		{"trunk()", `(trunk()):: & description(substring:"[task:")`},
+	}
+	for _, tt := range tests {
+		scope := config.ScopeConfig{}
+		if tt.scope != "" {
+			scope = parseScope(tt.scope)
+		}
+		got, err := tasksRevset(scope)
+		if err != nil || got != tt.want {
+			t.Errorf("tasksRevset(%q) = %s, %v; want %s", tt.scope, got, err, tt.want)
+		}
+	}
+
+	if _, err := tasksRevset(config.ScopeConfig{Depth: 10, All: true}); err == nil {
+		t.Error("want error for depth and all together")
+	}
+	if _, err := tasksRevset(parseScope("-5")); err == nil {
+		t.Error("want error for negative depth")
+	}
+}
+
+func TestApplyScope(t *testing.T) {
+	useStatusConfig(t, "[scope]\nbase = \"trunk()\"\n")
+	c := &jj.Client{}
+	if err := applyScope(c); err != nil {
+		t.Fatal(err)
+	}
+	want := `revset-aliases."tasks()"="(trunk()):: & description(substring:\"[task:\")"`
+	if !slices.Equal(c.Globals.Config, []string{want}) {
+		t.Errorf("overrides = %v, want %s", c.Globals.Config, want)
+	}
+
+	var out strings.Builder
+	if err := generateJJConfig(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"tasks()" = '(trunk()):: & description(substring:"[task:")'`) {
+		t.Error("generated config does not use the configured scope")
+	}
+}
+
+func TestExcludedPending(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns("abc\ndef\n")
+
+	n, err := excludedPending(client)
+	if err != nil || n != 2 {
+		t.Fatalf("excludedPending = %d, %v; want 2", n, err)
+	}
+	want := `log -r mutable() & description(substring:"[task:") ~ tasks() ~ description(substring:"[task:done]")`
+	if got := fake.Commands("log"); len(got) != 1 || !strings.HasPrefix(got[0], want) {
+		t.Errorf("calls = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
+)
+
+var showFormat string
+
+// RelatedTask is a task referenced from another task's details
+type RelatedTask struct {
+	ChangeID string `json:"change_id"`
+	Flag     string `json:"flag,omitempty"`
+	Title    string `json:"title"`
+}
+
+// ShowOutput is everything 'jjtask show' knows about a task
+type ShowOutput struct {
+	TaskItem
+	Body              string            `json:"body"`
+	Trailers          map[string]string `json:"trailers,omitempty"`
+	Parents           []RelatedTask     `json:"parents"`
+	Children          []RelatedTask     `json:"children"`
+	InWorkingCopy     bool              `json:"in_working_copy"`
+	BlockedAncestors  []RelatedTask     `json:"blocked_ancestors"`
+	PendingChildren   []RelatedTask     `json:"pending_children"`
+	UnmetDependencies []RelatedTask     `json:"unmet_dependencies"`
+	DiffStat          string            `json:"diff_stat,omitempty"`
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [task]",
+	Short: "Show a task with its context",
+	Long: `Show everything needed before starting on a task (default @): title,
+flag, spec, metadata, checklist progress, parent and child revisions,
+whether it is in @'s merge, blockers and the diff stat.
+
+Blockers are blocked ancestors, pending children and unfinished Depends-On
+tasks, the same checks 'jjtask flag' warns about.
+
+Examples:
+  jjtask show xyz
+  jjtask show xyz --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(showFormat); err != nil {
+			return err
+		}
+		rev := "@"
+		if len(args) > 0 {
+			rev = args[0]
+		}
+		g, err := taskGraph()
+		if err != nil {
+			return fmt.Errorf("loading tasks: %w", err)
+		}
+		t, err := g.Resolve(rev)
+		if err != nil {
+			return err
+		}
+
+		output := showDetails(g, t)
+		output.DiffStat = diffStat(t)
+
+		if showFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(output)
+		}
+		printShow(os.Stdout, output)
+		return nil
+	},
+}
+
+// showDetails gathers a task's context from the graph
+func showDetails(g *task.Graph, t *task.Task) ShowOutput {
+	out := ShowOutput{
+		TaskItem:          newTaskItem(t),
+		Body:              t.Body,
+		Parents:           relatedTasks(g.Parents(t.ChangeID)),
+		Children:          relatedTasks(g.Children(t.ChangeID)),
+		UnmetDependencies: relatedTasks(g.UnmetDependencies(t.ChangeID)),
+	}
+	for _, tr := range t.Trailers {
+		if out.Trailers == nil {
+			out.Trailers = make(map[string]string)
+		}
+		if prev, ok := out.Trailers[tr.Key]; ok {
+			out.Trailers[tr.Key] = prev + ", " + tr.Value
+		} else {
+			out.Trailers[tr.Key] = tr.Value
+		}
+	}
+
+	if at := g.WorkingCopy(); at != nil {
+		out.InWorkingCopy = t.WorkingCopy || slices.Contains(at.Parents, t.ChangeID)
+	}
+
+	var blocked, pending []*task.Task
+	for _, a := range g.Ancestors(t.ChangeID) {
+		if a.Flag == "blocked" {
+			blocked = append(blocked, a)
+		}
+	}
+	for _, c := range g.Children(t.ChangeID) {
+		if task.IsPending(c) {
+			pending = append(pending, c)
+		}
+	}
+	out.BlockedAncestors = relatedTasks(blocked)
+	out.PendingChildren = relatedTasks(pending)
+	return out
+}
+
+// diffStat returns the task's diff stat, or "" if it is empty
+func diffStat(t *task.Task) string {
+	if t.Empty {
+		return ""
+	}
+	stat, err := client.Query("diff", "-r", t.ChangeID, "--stat")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(stat, "\n")
+}
+
+func relatedTasks(tasks []*task.Task) []RelatedTask {
+	related := []RelatedTask{}
+	for _, t := range tasks {
+		title := t.Title
+		if !t.IsTask() {
+			title = t.FirstLine()
+		}
+		related = append(related, RelatedTask{ChangeID: t.Ref(), Flag: t.Flag, Title: title})
+	}
+	return related
+}
+
+func printShow(w io.Writer, out ShowOutput) {
+	if out.Flag != "" {
+		_, _ = fmt.Fprintf(w, "%s [task:%s] %s\n", out.ChangeID, out.Flag, out.Title)
+	} else {
+		_, _ = fmt.Fprintf(w, "%s %s\n", out.ChangeID, out.Title)
+	}
+	if out.Checklist != nil {
+		_, _ = fmt.Fprintf(w, "Checklist: %d/%d done\n", out.Checklist.Done, out.Checklist.Total)
+	}
+	if out.InWorkingCopy {
+		_, _ = fmt.Fprintln(w, "In @: yes")
+	} else {
+		_, _ = fmt.Fprintln(w, "In @: no")
+	}
+
+	if out.Body != "" {
+		_, _ = fmt.Fprintf(w, "\n%s\n", out.Body)
+	}
+
+	printSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		_, _ = fmt.Fprintf(w, "\n%s:\n", title)
+		for _, line := range lines {
+			_, _ = fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	relatedLines := func(related []RelatedTask) []string {
+		var lines []string
+		for _, r := range related {
+			if r.Flag != "" {
+				lines = append(lines, fmt.Sprintf("%s [task:%s] %s", r.ChangeID, r.Flag, r.Title))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s %s", r.ChangeID, r.Title))
+			}
+		}
+		return lines
+	}
+
+	var trailers []string
+	for _, key := range slices.Sorted(maps.Keys(out.Trailers)) {
+		trailers = append(trailers, key+": "+out.Trailers[key])
+	}
+	printSection("Metadata", trailers)
+	printSection("Parents", relatedLines(out.Parents))
+	printSection("Children", relatedLines(out.Children))
+	printSection("Blocked ancestors", relatedLines(out.BlockedAncestors))
+	printSection("Pending children", relatedLines(out.PendingChildren))
+	printSection("Unfinished dependencies", relatedLines(out.UnmetDependencies))
+	if out.DiffStat != "" {
+		printSection("Changes", strings.Split(out.DiffStat, "\n"))
+	}
+}
+
+func init() {
+	showCmd.Flags().StringVar(&showFormat, "format", "text", "Output format: text or json")
+	showCmd.ValidArgsFunction = completeTaskRevision
+	rootCmd.AddCommand(showCmd)
+}
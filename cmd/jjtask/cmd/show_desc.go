@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"regexp"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var (
@@ -39,49 +39,41 @@ Examples:
 			rev = args[0]
 		}
 
-		desc, err := client.GetDescription(rev)
+		output, err := showDesc(rev)
 		if err != nil {
 			return err
 		}
 
 		if showDescFormat == "json" {
-			changeID, _ := client.Query("log", "-r", rev, "--no-graph", "-T", "change_id.shortest()")
-			changeID = strings.TrimSpace(changeID)
-
-			lines := strings.SplitN(desc, "\n", 2)
-			firstLine := ""
-			if len(lines) > 0 {
-				firstLine = lines[0]
-			}
-
-			var taskFlag string
-			if re := regexp.MustCompile(`\[task:(\w+)\]`); re.MatchString(firstLine) {
-				if match := re.FindStringSubmatch(firstLine); match != nil {
-					taskFlag = match[1]
-				}
-			}
-
-			output := ShowDescOutput{
-				Revision:    rev,
-				ChangeID:    changeID,
-				Description: desc,
-				FirstLine:   firstLine,
-				TaskFlag:    taskFlag,
-			}
-
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetIndent("", "  ")
 			return enc.Encode(output)
 		}
 
-		fmt.Print(desc)
+		fmt.Print(output.Description)
 		return nil
 	},
 }
 
+// showDesc loads the description of rev
+func showDesc(rev string) (ShowDescOutput, error) {
+	t, err := task.Get(client, rev)
+	if err != nil {
+		return ShowDescOutput{}, err
+	}
+	return ShowDescOutput{
+		Revision:    rev,
+		ChangeID:    t.ChangeID,
+		Description: t.Description,
+		FirstLine:   t.FirstLine(),
+		TaskFlag:    t.Flag,
+	}, nil
+}
+
 func init() {
 	showDescCmd.Flags().StringVarP(&showDescRev, "rev", "r", "@", "revision to show")
 	showDescCmd.Flags().StringVar(&showDescFormat, "format", "text", "Output format: text or json")
+	withRepoRefs(showDescCmd, &showDescRev)
 	rootCmd.AddCommand(showDescCmd)
 	_ = showDescCmd.RegisterFlagCompletionFunc("rev", completeRevision)
 }
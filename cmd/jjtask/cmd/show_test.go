@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/task"
+)
+
+func TestShowDetails(t *testing.T) {
+	nodes, err := task.Parse(logLines(t,
+		"at w base: work",
+		"c w: [task:todo] C",
+		"w b: [task:wip] W\n\nSpec\n- [x] one\n- [ ] two\n\nDepends-On: d\nPriority: high\n",
+		"d base: [task:todo] D",
+		"b base: [task:blocked] B",
+		"base: Base",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := task.NewGraph(nodes)
+
+	out := showDetails(g, g.Get("w"))
+	if !out.InWorkingCopy {
+		t.Error("w is a parent of @, want InWorkingCopy")
+	}
+	if out.Checklist == nil || out.Checklist.Done != 1 || out.Checklist.Total != 2 {
+		t.Errorf("Checklist = %+v, want 1/2", out.Checklist)
+	}
+	if out.Body != "Spec\n- [x] one\n- [ ] two" || out.Trailers["Priority"] != "high" {
+		t.Errorf("Body = %q, Trailers = %v", out.Body, out.Trailers)
+	}
+
+	related := func(rs []RelatedTask) []string {
+		var ids []string
+		for _, r := range rs {
+			ids = append(ids, r.ChangeID)
+		}
+		return ids
+	}
+	for name, tt := range map[string]struct{ got, want []string }{
+		"parents":      {related(out.Parents), []string{"b"}},
+		"children":     {related(out.Children), []string{"at", "c"}},
+		"blocked":      {related(out.BlockedAncestors), []string{"b"}},
+		"pending":      {related(out.PendingChildren), []string{"c"}},
+		"dependencies": {related(out.UnmetDependencies), []string{"d"}},
+	} {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestShowRejectsFormat(t *testing.T) {
+	showFormat = "yaml"
+	t.Cleanup(func() { showFormat = "text" })
+	if err := showCmd.RunE(showCmd, nil); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("err = %v, want invalid format", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var squashKeepTasks bool
@@ -23,42 +25,26 @@ Examples:
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get parents of @ (the merged tasks)
-		parentsOut, err := client.Query("log", "-r", "parents(@)", "--no-graph", "-T", `change_id.shortest() ++ "\n"`)
+		parents, err := task.Load(client, "parents(@)")
 		if err != nil {
 			return fmt.Errorf("failed to get parents: %w", err)
 		}
 
-		var parents []string
-		for _, line := range strings.Split(strings.TrimSpace(parentsOut), "\n") {
-			if line != "" {
-				parents = append(parents, line)
-			}
-		}
-
 		if len(parents) == 0 {
-			fmt.Println("No parents to squash")
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No parents to squash")
 			return nil
 		}
 
 		if len(parents) == 1 {
-			fmt.Println("Only one parent, nothing to merge-squash")
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Only one parent, nothing to merge-squash")
 			return nil
 		}
 
 		// Build combined commit message from task descriptions
 		var msgParts []string
 		for _, p := range parents {
-			desc, err := client.GetDescription(p)
-			if err != nil || desc == "" {
-				continue
-			}
 			// Strip [task:*] prefix for cleaner message
-			desc = strings.TrimSpace(desc)
-			if strings.HasPrefix(desc, "[task:") {
-				if idx := strings.Index(desc, "]"); idx != -1 {
-					desc = strings.TrimSpace(desc[idx+1:])
-				}
-			}
+			desc := strings.TrimSpace(task.StripFlag(strings.TrimSpace(p.Description)))
 			if desc != "" {
 				msgParts = append(msgParts, "- "+strings.Split(desc, "\n")[0])
 			}
@@ -71,18 +57,22 @@ Examples:
 			return fmt.Errorf("failed to squash: %w", err)
 		}
 
-		fmt.Printf("Squashed %d tasks into linear commit\n", len(parents))
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Squashed %d tasks into linear commit\n", len(parents))
 
 		if !squashKeepTasks {
-			// Mark original tasks as done (they're now empty after squash)
-			for _, p := range parents {
-				desc, err := client.GetDescription(p)
-				if err != nil {
-					continue
-				}
-				if strings.Contains(desc, "[task:wip]") {
-					newDesc := strings.Replace(desc, "[task:wip]", "[task:done]", 1)
-					_ = client.SetDescription(p, newDesc)
+			// Mark original tasks as done (they're now empty after squash).
+			// Emptied parents may have been abandoned, so only revisit present ones.
+			var present []string
+			for _, id := range task.IDs(parents) {
+				present = append(present, "present("+id+")")
+			}
+			remaining, err := task.Load(client, strings.Join(present, " | "))
+			if err != nil {
+				return nil
+			}
+			for _, p := range remaining {
+				if p.Flag == "wip" {
+					_ = client.SetDescription(p.ChangeID, task.SetFlag(p.Description, "done"))
 				}
 			}
 		}
@@ -92,6 +82,7 @@ Examples:
 }
 
 func init() {
+	withResultFormat(squashCmd)
 	rootCmd.AddCommand(squashCmd)
 	squashCmd.Flags().BoolVar(&squashKeepTasks, "keep-tasks", false, "Keep task revisions after squash")
 }
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var staleCmd = &cobra.Command{
@@ -22,23 +23,20 @@ Use jj abandon to clean up superseded/orphaned tasks.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Find done tasks not in @'s ancestry
-		out, err := client.Query("log", "-r", "tasks_done() ~ ::@", "--no-graph", "-T", `change_id.shortest() ++ " " ++ description.first_line() ++ "\n"`)
+		stale, err := task.Load(client, "tasks_done() ~ ::@")
 		if err != nil {
 			return fmt.Errorf("failed to find stale tasks: %w", err)
 		}
 
-		out = strings.TrimSpace(out)
-		if out == "" {
+		if len(stale) == 0 {
 			fmt.Println("No stale done tasks found")
 			return nil
 		}
 
 		fmt.Println("Stale done tasks (not in @'s ancestry):")
 		fmt.Println()
-		for _, line := range strings.Split(out, "\n") {
-			if line != "" {
-				fmt.Println("  " + line)
-			}
+		for _, t := range stale {
+			fmt.Println("  " + t.ChangeID + " " + t.FirstLine())
 		}
 		fmt.Println()
 		fmt.Println("These may be superseded, orphaned, or exploratory.")
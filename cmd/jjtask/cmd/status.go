@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
+)
+
+// builtinFlags are the statuses jjtask always knows
+var builtinFlags = []string{"draft", "todo", "wip", "untested", "standby", "review", "blocked", "done"}
+
+// reservedStatuses are find filters that cannot be used as status names
+var reservedStatuses = []string{"pending", "ready", "all"}
+
+var (
+	// validFlags lists built-in and configured statuses
+	validFlags = builtinFlags
+	// customStatuses holds the [[statuses.custom]] entries in use
+	customStatuses []config.CustomStatus
+	// statusTransitions holds [statuses.transitions]
+	statusTransitions map[string][]string
+)
+
+var statusNamePattern = regexp.MustCompile(`^\w+$`)
+
+// jjColors maps jj color names to ANSI codes and fill colors
+var jjColors = map[string][2]string{
+	"black":          {"30", "#45475a"},
+	"red":            {"31", "#f38ba8"},
+	"green":          {"32", "#a6e3a1"},
+	"yellow":         {"33", "#f9e2af"},
+	"blue":           {"34", "#89b4fa"},
+	"magenta":        {"35", "#f5c2e7"},
+	"cyan":           {"36", "#89dceb"},
+	"white":          {"37", "#bac2de"},
+	"bright black":   {"90", "#d0d0d0"},
+	"bright red":     {"91", "#f38ba8"},
+	"bright green":   {"92", "#a6e3a1"},
+	"bright yellow":  {"93", "#f9e2af"},
+	"bright blue":    {"94", "#89b4fa"},
+	"bright magenta": {"95", "#f5c2e7"},
+	"bright cyan":    {"96", "#89dceb"},
+	"bright white":   {"97", "#bac2de"},
+}
+
+// loadStatuses reads and validates [statuses] from .jjtask.toml
+func loadStatuses() (config.StatusesConfig, error) {
+	cfg, err := config.GetStatusesConfig()
+	if err != nil {
+		return cfg, err
+	}
+
+	flags := slices.Clone(builtinFlags)
+	for _, s := range cfg.Custom {
+		switch {
+		case !statusNamePattern.MatchString(s.Name):
+			return cfg, fmt.Errorf("[statuses] invalid status name %q, use letters, digits and underscores", s.Name)
+		case slices.Contains(flags, s.Name) || slices.Contains(reservedStatuses, s.Name):
+			return cfg, fmt.Errorf("[statuses] status %q is already defined", s.Name)
+		case s.Color != "" && jjColors[s.Color] == [2]string{}:
+			return cfg, fmt.Errorf("[statuses] status %q: unknown color %q", s.Name, s.Color)
+		}
+		flags = append(flags, s.Name)
+	}
+	for from, targets := range cfg.Transitions {
+		for _, flag := range append([]string{from}, targets...) {
+			if !slices.Contains(flags, flag) {
+				return cfg, fmt.Errorf("[statuses.transitions] unknown status %q, must be one of: %s", flag, strings.Join(flags, ", "))
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// applyStatuses makes configured statuses valid flags, counts the done ones
+// as complete and passes their revset aliases, task_flag template and
+// colors to jj through c
+func applyStatuses(c *jj.Client) error {
+	cfg, err := loadStatuses()
+	if err != nil {
+		return err
+	}
+	customStatuses, statusTransitions = cfg.Custom, cfg.Transitions
+	validFlags = slices.Clone(builtinFlags)
+	task.DoneFlags = []string{"done"}
+	for _, s := range cfg.Custom {
+		validFlags = append(validFlags, s.Name)
+		if s.Done {
+			task.DoneFlags = append(task.DoneFlags, s.Name)
+		}
+		if color, ok := jjColors[s.Color]; ok {
+			flagANSI[s.Name], flagColors[s.Name] = color[0], color[1]
+			if !slices.Contains(flagOrder, s.Name) {
+				flagOrder = append(flagOrder, s.Name)
+			}
+		}
+	}
+	c.Globals.Config = append(c.Globals.Config, statusOverrides(cfg.Custom)...)
+	return nil
+}
+
+// statusOverrides returns --config values teaching jj about custom statuses
+func statusOverrides(custom []config.CustomStatus) []string {
+	if len(custom) == 0 {
+		return nil
+	}
+	overrides := []string{
+		"template-aliases.task_flag=" + strconv.Quote(taskFlagTemplate(validFlags)),
+		`revset-aliases."tasks_pending()"=` + strconv.Quote(pendingRevset()),
+	}
+	for _, s := range custom {
+		overrides = append(overrides, fmt.Sprintf(`revset-aliases."tasks_%s()"=%s`, s.Name, strconv.Quote(flagRevset(s.Name))))
+		if s.Color != "" {
+			overrides = append(overrides, fmt.Sprintf(`colors."task %s"=%s`, s.Name, strconv.Quote(s.Color)))
+		}
+	}
+	return overrides
+}
+
+// taskFlagTemplate builds the task_flag template alias for flags
+func taskFlagTemplate(flags []string) string {
+	var b strings.Builder
+	for _, flag := range flags {
+		fmt.Fprintf(&b, "if(description.starts_with(\"[task:%s]\"), %q,\n", flag, flag)
+	}
+	b.WriteString(`""` + strings.Repeat(")", len(flags)))
+	return b.String()
+}
+
+// checkTransition refuses flag changes not allowed by [statuses.transitions].
+// Statuses without an entry may change to anything.
+func checkTransition(rev, to string) error {
+	g, err := taskGraph()
+	if err != nil {
+		return err
+	}
+	t, err := g.Resolve(rev)
+	if err != nil {
+		return err
+	}
+	allowed, ok := statusTransitions[t.Flag]
+	if !ok || t.Flag == to || slices.Contains(allowed, to) {
+		return nil
+	}
+	targets := strings.Join(allowed, ", ")
+	if targets == "" {
+		targets = "nothing"
+	}
+	return fmt.Errorf("%s cannot go from %s to %s, [statuses.transitions] allows: %s (use --force to override)",
+		t.ChangeID, t.Flag, to, targets)
+}
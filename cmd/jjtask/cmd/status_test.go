@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj"
+	"jjtask/internal/task"
+)
+
+// useStatusConfig runs the test in a directory with .jjtask.toml containing
+// content, restoring the status vocabulary afterwards
+func useStatusConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".jjtask.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	config.Reset()
+	prevOrder, prevKeys := flagOrder, task.KnownKeys
+	t.Cleanup(func() {
+		task.KnownKeys = prevKeys
+		config.Reset()
+		for _, s := range customStatuses {
+			delete(flagANSI, s.Name)
+			delete(flagColors, s.Name)
+		}
+		validFlags, customStatuses, statusTransitions = builtinFlags, nil, nil
+		task.DoneFlags, flagOrder = []string{"done"}, prevOrder
+	})
+}
+
+func TestApplyStatuses(t *testing.T) {
+	useStatusConfig(t, `
+[[statuses.custom]]
+name = "design"
+color = "magenta"
+
+[[statuses.custom]]
+name = "shipped"
+done = true
+`)
+	c := &jj.Client{}
+	if err := applyStatuses(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(validFlags, "design") || !slices.Contains(validFlags, "shipped") {
+		t.Errorf("validFlags = %v, want custom statuses added", validFlags)
+	}
+	if !task.IsDone("shipped") || task.IsDone("design") {
+		t.Errorf("DoneFlags = %v, want shipped counted as done", task.DoneFlags)
+	}
+	if flagANSI["design"] != "35" || !slices.Contains(flagOrder, "design") {
+		t.Errorf("design color not registered: %q %v", flagANSI["design"], flagOrder)
+	}
+
+	want := []string{
+		`revset-aliases."tasks_pending()"="tasks() & ~description(substring:\"[task:done]\") & ~description(substring:\"[task:shipped]\")"`,
+		`revset-aliases."tasks_design()"="tasks() & description(substring:\"[task:design]\")"`,
+		`colors."task design"="magenta"`,
+	}
+	for _, w := range want {
+		if !slices.Contains(c.Globals.Config, w) {
+			t.Errorf("config overrides missing %s\ngot: %v", w, c.Globals.Config)
+		}
+	}
+	if !strings.Contains(c.Globals.Config[0], `[task:design]\"), \"design\"`) {
+		t.Errorf("task_flag override = %s, want design branch", c.Globals.Config[0])
+	}
+}
+
+func TestApplyStatusesWithoutConfig(t *testing.T) {
+	useStatusConfig(t, "")
+	c := &jj.Client{}
+	if err := applyStatuses(c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Globals.Config) != 0 {
+		t.Errorf("overrides = %v, want none", c.Globals.Config)
+	}
+}
+
+func TestLoadStatusesRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"builtin name", "[[statuses.custom]]\nname = \"wip\"", `status "wip" is already defined`},
+		{"reserved name", "[[statuses.custom]]\nname = \"ready\"", `status "ready" is already defined`},
+		{"bad name", "[[statuses.custom]]\nname = \"in review\"", `invalid status name "in review"`},
+		{"bad color", "[[statuses.custom]]\nname = \"qa\"\ncolor = \"mauve\"", `unknown color "mauve"`},
+		{"unknown transition", "[statuses.transitions]\ntodo = [\"qa\"]", `unknown status "qa"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useStatusConfig(t, tt.content)
+			_, err := loadStatuses()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at x: work", "x base: [task:todo] X", "base: Base"))
+	statusTransitions = map[string][]string{"todo": {"wip", "blocked"}}
+	t.Cleanup(func() { statusTransitions = nil })
+
+	for _, to := range []string{"wip", "todo"} {
+		if err := checkTransition("x", to); err != nil {
+			t.Errorf("todo -> %s: %v", to, err)
+		}
+	}
+	err := checkTransition("x", "done")
+	if err == nil || !strings.Contains(err.Error(), "x cannot go from todo to done, [statuses.transitions] allows: wip, blocked") {
+		t.Errorf("todo -> done: err = %v", err)
+	}
+	if err := checkTransition("at", "done"); err != nil {
+		t.Errorf("non-task: %v", err)
+	}
+}
+
+func TestApplyMetadataKeys(t *testing.T) {
+	useStatusConfig(t, "[metadata]\nkeys = [\"reviewer\", \"priority\"]\n")
+	if err := applyMetadataKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(slices.DeleteFunc(slices.Clone(task.KnownKeys), func(k string) bool { return k != "Priority" })); n != 1 {
+		t.Errorf("Priority registered %d times", n)
+	}
+	desc := task.SetTrailer("[task:todo] A", "Reviewer", "alice")
+	if _, trailers := task.SplitTrailers(desc); trailers.Get("Reviewer") != "alice" {
+		t.Errorf("trailers = %v, want Reviewer: alice", trailers)
+	}
+}
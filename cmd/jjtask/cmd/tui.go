@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"jjtask/internal/task"
+)
+
+// flagANSI mirrors the [colors] palette in 10-jjtask.toml as ANSI codes
+var flagANSI = map[string]string{
+	"done":     "32",
+	"todo":     "33",
+	"wip":      "36",
+	"blocked":  "31",
+	"standby":  "90",
+	"draft":    "90;3",
+	"untested": "35",
+	"review":   "34",
+}
+
+const tuiHelp = "j/k move  w wip  d done  x drop  f flag  c new  e edit  a all  q quit"
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and operate on tasks interactively",
+	Long: `Open a terminal UI with the task tree on the left and the selected
+task's spec, blockers and diff stat on the right.
+
+Keys:
+  j/k, ↓/↑   move selection       g/G   first/last task
+  w          mark WIP (like 'jjtask wip')
+  d          mark done (like 'jjtask done')
+  x          drop from @ (like 'jjtask drop')
+  f          set flag (prompts for the status)
+  c          create a child task (prompts for the title)
+  e          edit the description in $EDITOR
+  a          toggle done tasks
+  r          reload from jj (actions also reload first)
+  q          quit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+			return fmt.Errorf("jjtask tui needs an interactive terminal")
+		}
+		s := &tuiState{cmd: cmd, diffs: make(map[string]string)}
+		if err := s.reload(); err != nil {
+			return err
+		}
+
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		screen := os.Stdout
+		enter := func() { _, _ = screen.WriteString("\x1b[?1049h\x1b[?25l") }
+		leave := func() { _, _ = screen.WriteString("\x1b[?25h\x1b[?1049l") }
+		enter()
+		defer func() {
+			leave()
+			_ = term.Restore(fd, oldState)
+		}()
+
+		keys := bufio.NewReader(os.Stdin)
+		for {
+			width, height, err := term.GetSize(int(screen.Fd()))
+			if err != nil {
+				width, height = 80, 24
+			}
+			_, _ = screen.WriteString("\x1b[H\x1b[2J" + strings.Join(s.frame(width, height), "\r\n"))
+
+			key := readKey(keys)
+			switch key {
+			case "q", "ctrl-c":
+				return nil
+			case "e":
+				// Hand the terminal to jj's editor
+				t := s.current()
+				if t == nil {
+					continue
+				}
+				leave()
+				_ = term.Restore(fd, oldState)
+				err := client.Run("describe", "-r", t.ChangeID)
+				oldState, _ = term.MakeRaw(fd)
+				enter()
+				s.finish("Edited "+t.ChangeID, "", err)
+			case "f":
+				flag, ok := s.prompt(keys, screen, "flag ("+strings.Join(validFlags, ", ")+"): ", width, height)
+				if ok {
+					s.handleFlag(flag)
+				}
+			case "c":
+				title, ok := s.prompt(keys, screen, "new child title: ", width, height)
+				if ok && title != "" {
+					s.handleCreate(title)
+				}
+			default:
+				s.handleKey(key)
+			}
+		}
+	},
+}
+
+// tuiRow is a task in the tree, indented below its closest task ancestors
+type tuiRow struct {
+	task  *task.Task
+	depth int
+}
+
+// tuiState is the TUI model: rows, selection and the status line
+type tuiState struct {
+	cmd      *cobra.Command
+	showAll  bool
+	rows     []tuiRow
+	selected int
+	status   string
+	diffs    map[string]string // commit ID -> diff stat
+}
+
+// tuiRows lists pending (or all) tasks parents first, with their depth in
+// the task tree
+func tuiRows(g *task.Graph, all bool) []tuiRow {
+	keep := func(t *task.Task) bool {
+		return t.IsTask() && (all || task.IsPending(t))
+	}
+	depth := make(map[string]int)
+	var rows []tuiRow
+	nodes := g.Nodes()
+	for i := len(nodes) - 1; i >= 0; i-- {
+		t := nodes[i]
+		if !keep(t) {
+			continue
+		}
+		for _, p := range g.ClosestAncestors(t.ChangeID, keep) {
+			depth[t.ChangeID] = max(depth[t.ChangeID], depth[p.ChangeID]+1)
+		}
+		rows = append(rows, tuiRow{task: t, depth: depth[t.ChangeID]})
+	}
+	return rows
+}
+
+// reload rebuilds the rows, keeping the selection on the same task
+func (s *tuiState) reload() error {
+	g, err := taskGraph()
+	if err != nil {
+		return fmt.Errorf("loading tasks: %w", err)
+	}
+	var current string
+	if t := s.selectedTask(); t != nil {
+		current = t.ChangeID
+	}
+	s.rows = tuiRows(g, s.showAll)
+	s.selected = max(0, min(s.selected, len(s.rows)-1))
+	for i, r := range s.rows {
+		if r.task.ChangeID == current {
+			s.selected = i
+		}
+	}
+	return nil
+}
+
+func (s *tuiState) selectedTask() *task.Task {
+	if s.selected < 0 || s.selected >= len(s.rows) {
+		return nil
+	}
+	return s.rows[s.selected].task
+}
+
+// handleKey applies navigation keys and single-key actions
+func (s *tuiState) handleKey(key string) {
+	switch key {
+	case "j", "down":
+		s.selected = min(s.selected+1, len(s.rows)-1)
+	case "k", "up":
+		s.selected = max(s.selected-1, 0)
+	case "g":
+		s.selected = 0
+	case "G":
+		s.selected = len(s.rows) - 1
+	case "a":
+		s.showAll = !s.showAll
+		s.finish("", "", nil)
+	case "r":
+		if err := s.refresh(); err != nil {
+			s.status = "error: " + err.Error()
+		} else {
+			s.status = "Refreshed"
+		}
+	case "w":
+		s.act("Started", func(id string) error { return startTasks([]string{id}) })
+	case "d":
+		s.act("Done", func(id string) error {
+			if err := checkDone(s.cmd, []string{id}); err != nil {
+				return err
+			}
+			_, _, err := markDone(s.cmd, id)
+			return err
+		})
+	case "x":
+		s.act("Dropped", func(id string) error { return dropTask(s.cmd, id) })
+	}
+}
+
+func (s *tuiState) handleFlag(flag string) {
+	flag = strings.TrimSpace(flag)
+	if !slices.Contains(validFlags, flag) {
+		s.status = fmt.Sprintf("invalid flag %q", flag)
+		return
+	}
+	s.act("Flagged "+flag, func(id string) error {
+		if err := checkTransition(id, flag); err != nil {
+			return err
+		}
+		return setTaskFlag(id, flag)
+	})
+}
+
+func (s *tuiState) handleCreate(title string) {
+	s.act("Created child of", func(id string) error { return runCreate(s.cmd, []string{id, title}) })
+}
+
+// refresh reloads the task graph from jj, picking up changes made outside
+// the TUI, and drops cached diff stats
+func (s *tuiState) refresh() error {
+	graph = nil
+	clear(s.diffs)
+	return s.reload()
+}
+
+// current refreshes and returns the selected task, or nil with the status
+// line set if it changed outside the TUI
+func (s *tuiState) current() *task.Task {
+	prev := s.selectedTask()
+	if prev == nil {
+		return nil
+	}
+	if err := s.refresh(); err != nil {
+		s.status = "error: " + err.Error()
+		return nil
+	}
+	if t := s.selectedTask(); t != nil && t.ID == prev.ID {
+		return t
+	}
+	s.status = "error: " + prev.ChangeID + " is gone, nothing was changed"
+	return nil
+}
+
+// act runs fn on the selected task with its output captured for the status
+// line, then reloads
+func (s *tuiState) act(label string, fn func(id string) error) {
+	t := s.current()
+	if t == nil {
+		return
+	}
+	var out strings.Builder
+	err := redirectOutput(s.cmd, &out, func() error { return fn(t.ChangeID) })
+	s.finish(label+" "+t.ChangeID, out.String(), err)
+}
+
+// finish sets the status line from an action's result and reloads
+func (s *tuiState) finish(success, output string, err error) {
+	switch {
+	case err != nil:
+		s.status = "error: " + firstLine(err.Error())
+	case success != "":
+		s.status = success
+	default:
+		s.status = ""
+	}
+	if err == nil {
+		if last := lastLine(output); last != "" {
+			s.status += " — " + last
+		}
+	}
+	if err := s.reload(); err != nil {
+		s.status = "error: " + err.Error()
+	}
+}
+
+// prompt reads a line of input on the status line; Esc cancels
+func (s *tuiState) prompt(keys *bufio.Reader, screen io.Writer, label string, width, height int) (string, bool) {
+	var input []rune
+	for {
+		lines := s.frame(width, height)
+		lines[len(lines)-1] = truncate(label+string(input), width)
+		_, _ = io.WriteString(screen, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+
+		switch key := readKey(keys); key {
+		case "enter":
+			return string(input), true
+		case "esc", "ctrl-c":
+			return "", false
+		case "backspace":
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		default:
+			if r := []rune(key); len(r) == 1 {
+				input = append(input, r[0])
+			}
+		}
+	}
+}
+
+// frame renders the screen as height lines of at most width columns
+func (s *tuiState) frame(width, height int) []string {
+	leftWidth := max(20, width*2/5)
+	rightWidth := max(0, width-leftWidth-3)
+	bodyHeight := max(1, height-2)
+
+	scope := "pending"
+	if s.showAll {
+		scope = "all"
+	}
+	lines := []string{truncate(fmt.Sprintf("jjtask — %d %s tasks", len(s.rows), scope), width)}
+
+	// Scroll so the selection stays visible
+	offset := 0
+	if s.selected >= bodyHeight {
+		offset = s.selected - bodyHeight + 1
+	}
+	preview := s.preview()
+	for i := range bodyHeight {
+		left := ""
+		if row := offset + i; row < len(s.rows) {
+			left = s.rowText(s.rows[row], row == s.selected, leftWidth)
+		} else {
+			left = strings.Repeat(" ", leftWidth)
+		}
+		right := ""
+		if i < len(preview) {
+			right = truncate(preview[i], rightWidth)
+		}
+		lines = append(lines, left+" │ "+right)
+	}
+
+	status := s.status
+	if status == "" {
+		status = tuiHelp
+	}
+	return append(lines, truncate(status, width))
+}
+
+// rowText renders a tree row padded to width, colored by flag
+func (s *tuiState) rowText(r tuiRow, selected bool, width int) string {
+	text := truncate(fmt.Sprintf("%s%s [%s] %s", strings.Repeat("  ", r.depth), r.task.ChangeID, r.task.Flag, r.task.Title), width)
+	text += strings.Repeat(" ", max(0, width-len([]rune(text))))
+	code := flagANSI[r.task.Flag]
+	if selected {
+		code += ";7"
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// preview renders the selected task like 'jjtask show'
+func (s *tuiState) preview() []string {
+	t := s.selectedTask()
+	if t == nil {
+		return []string{"No tasks"}
+	}
+	g, err := taskGraph()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	out := showDetails(g, t)
+	stat, ok := s.diffs[t.CommitID]
+	if !ok {
+		stat = diffStat(t)
+		s.diffs[t.CommitID] = stat
+	}
+	out.DiffStat = stat
+
+	var buf bytes.Buffer
+	printShow(&buf, out)
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// readKey reads one keypress, decoding arrow keys and control characters
+func readKey(r *bufio.Reader) string {
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return "ctrl-c"
+	}
+	switch ch {
+	case 3:
+		return "ctrl-c"
+	case '\r', '\n':
+		return "enter"
+	case 127, 8:
+		return "backspace"
+	case 27:
+		if r.Buffered() == 0 {
+			return "esc"
+		}
+		if next, _ := r.ReadByte(); next != '[' {
+			return "esc"
+		}
+		switch code, _ := r.ReadByte(); code {
+		case 'A':
+			return "up"
+		case 'B':
+			return "down"
+		}
+		return ""
+	}
+	return string(ch)
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:max(0, width)])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func firstLine(s string) string {
+	first, _, _ := strings.Cut(s, "\n")
+	return first
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"jjtask/internal/task"
+)
+
+func TestTUIRows(t *testing.T) {
+	nodes, err := task.Parse(logLines(t,
+		"at c: work",
+		"c m: [task:todo] C",
+		"m b: merge",
+		"b a: [task:wip] B",
+		"d a: [task:done] D",
+		"a base: [task:todo] A",
+		"base: Base",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := task.NewGraph(nodes)
+
+	var got []string
+	for _, r := range tuiRows(g, false) {
+		got = append(got, strings.Repeat(".", r.depth)+r.task.ChangeID)
+	}
+	if want := "a .b ..c"; strings.Join(got, " ") != want {
+		t.Errorf("rows = %q, want %q", strings.Join(got, " "), want)
+	}
+	if n := len(tuiRows(g, true)); n != 4 {
+		t.Errorf("rows with done = %d, want 4", n)
+	}
+}
+
+func TestTUIFrame(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t,
+		"at b: work",
+		"b a: [task:wip] B\n\nSpec for B",
+		"a base: [task:todo] A",
+		"base: Base",
+	))
+
+	s := &tuiState{diffs: make(map[string]string)}
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+	s.handleKey("j")
+	if got := s.selectedTask().ChangeID; got != "b" {
+		t.Fatalf("selected %s after j, want b", got)
+	}
+
+	lines := s.frame(80, 10)
+	if len(lines) != 10 {
+		t.Fatalf("frame has %d lines, want 10", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"2 pending tasks", "b [task:wip] B", "Spec for B", tuiHelp} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("frame missing %q:\n%s", want, screen)
+		}
+	}
+}
+
+func TestTUIReloadsBeforeAction(t *testing.T) {
+	fake := useFake(t)
+	fake.On("log").Returns(logLines(t, "at b: work", "b a: [task:todo] B", "a base: [task:todo] A", "base: Base")).Once()
+	// b was abandoned outside the TUI
+	fake.On("log").Returns(logLines(t, "at a: work", "a base: [task:todo] A", "base: Base"))
+
+	s := &tuiState{diffs: map[string]string{"stale": "1 file changed"}}
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+	s.handleKey("j")
+	s.handleKey("w")
+
+	if !strings.Contains(s.status, "b is gone") {
+		t.Errorf("status = %q, want b is gone", s.status)
+	}
+	if got := fake.Commands("describe", "rebase"); len(got) != 0 {
+		t.Errorf("unexpected commands: %v", got)
+	}
+	if len(s.diffs) != 0 || len(s.rows) != 1 {
+		t.Errorf("diffs = %v, rows = %d; want a fresh reload", s.diffs, len(s.rows))
+	}
+}
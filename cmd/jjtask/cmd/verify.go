@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/task"
+)
+
+// verifyFailure describes the first verification command that failed
+type verifyFailure struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (f *verifyFailure) Error() string {
+	return fmt.Sprintf("verify %q failed: %v", f.Command, f.Err)
+}
+
+// verifyCommands lists the commands done must run for t: project-wide
+// [done] verify entries first, then the task's own Verify trailers
+func verifyCommands(cfg config.DoneConfig, t *task.Task) []string {
+	var cmds []string
+	for _, c := range append(slices.Clone(cfg.Verify), t.Verify...) {
+		c = strings.TrimSpace(c)
+		if c != "" && !slices.Contains(cmds, c) {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+// runVerify runs commands with sh in dir, stopping at the first failure.
+// Commands see the task's change ID in $JJTASK_TASK.
+func runVerify(cmd *cobra.Command, dir string, t *task.Task, commands []string) *verifyFailure {
+	stderr := cmd.ErrOrStderr()
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(stderr, "Verifying %s: %s\n", t.ChangeID, c)
+		sh := exec.Command("sh", "-c", c)
+		sh.Dir = dir
+		sh.Env = append(os.Environ(), "JJTASK_TASK="+t.ChangeID)
+		out, err := sh.CombinedOutput()
+		if err != nil {
+			return &verifyFailure{Command: c, Output: string(out), Err: err}
+		}
+	}
+	return nil
+}
+
+// verifyTask runs the verification commands for t before it is marked done.
+// On failure the task is flagged with the configured on_fail status, the
+// command output is shown, and an error is returned.
+func verifyTask(cmd *cobra.Command, t *task.Task, inWorkingCopy bool) error {
+	if doneNoVerify {
+		return nil
+	}
+	cfg, err := config.GetDoneConfig()
+	if err != nil {
+		return err
+	}
+	commands := verifyCommands(cfg, t)
+	if len(commands) == 0 {
+		return nil
+	}
+
+	// The commands run in the working copy, which would not contain the
+	// task's changes
+	if !inWorkingCopy {
+		return fmt.Errorf("cannot verify %s: it is not in @'s ancestry (jj edit it, or pass --no-verify)", t.ChangeID)
+	}
+
+	stderr := cmd.ErrOrStderr()
+	if client.DryRun {
+		for _, c := range commands {
+			_, _ = fmt.Fprintf(stderr, "Would verify %s: %s\n", t.ChangeID, c)
+		}
+		return nil
+	}
+
+	dir, err := client.Root()
+	if err != nil {
+		return fmt.Errorf("finding repo root: %w", err)
+	}
+
+	failure := runVerify(cmd, dir, t, commands)
+	if failure == nil {
+		return nil
+	}
+
+	_, _ = fmt.Fprint(stderr, failure.Output)
+	if !strings.HasSuffix(failure.Output, "\n") && failure.Output != "" {
+		_, _ = fmt.Fprintln(stderr)
+	}
+	if err := client.SetDescription(t.ChangeID, task.SetFlag(t.Description, cfg.OnFail)); err != nil {
+		return fmt.Errorf("%w; flagging %s: %v", failure, cfg.OnFail, err)
+	}
+	return fmt.Errorf("%w; flagged %s instead", failure, cfg.OnFail)
+}
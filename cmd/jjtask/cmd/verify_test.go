@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+	"jjtask/internal/jj/jjtest"
+	"jjtask/internal/task"
+)
+
+func TestVerifyCommands(t *testing.T) {
+	cfg := config.DoneConfig{Verify: []string{"go vet ./...", "go test ./..."}}
+	tk := &task.Task{Verify: []string{"go test ./...", " make lint "}}
+
+	got := verifyCommands(cfg, tk)
+	want := []string{"go vet ./...", "go test ./...", "make lint"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("verifyCommands = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyTaskFlagsFailure(t *testing.T) {
+	t.Setenv("JJ_WORKSPACE_ROOT", t.TempDir())
+	fake := jjtest.New()
+	fake.On("describe")
+	prevClient := client
+	client = fake.Client()
+	t.Cleanup(func() { client = prevClient })
+
+	desc := "[task:wip] Add cache\n\nVerify: true\nVerify: echo boom; exit 3\n"
+	tasks, err := task.Parse(logLines(t, "a base: "+desc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	err = verifyTask(cmd, tasks[0], true)
+	if err == nil || !strings.Contains(err.Error(), "flagged untested") {
+		t.Fatalf("verifyTask = %v, want failure flagged untested", err)
+	}
+	if !strings.Contains(stderr.String(), "boom") {
+		t.Errorf("stderr = %q, want command output", stderr.String())
+	}
+	calls := fake.Calls()
+	if len(calls) != 1 || !strings.HasPrefix(calls[0].Stdin, "[task:untested] Add cache") {
+		t.Errorf("calls = %v, want describe flagging untested", calls)
+	}
+}
+
+func TestVerifyTaskOutsideWorkingCopy(t *testing.T) {
+	fake := jjtest.New()
+	prevClient := client
+	client = fake.Client()
+	t.Cleanup(func() { client = prevClient })
+
+	tasks, err := task.Parse(logLines(t, "a base: [task:wip] Add cache\n\nVerify: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = verifyTask(&cobra.Command{}, tasks[0], false)
+	if err == nil || !strings.Contains(err.Error(), "not in @'s ancestry") {
+		t.Fatalf("verifyTask = %v, want refusal outside @'s ancestry", err)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("calls = %v, want none", calls)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"jjtask/internal/config"
+)
+
+// Warning codes, usable in the [warnings] section of .jjtask.toml
+const (
+	WarnExistingWip        = "existing-wip"
+	WarnDoneAncestor       = "done-ancestor"
+	WarnBlockedAncestor    = "blocked-ancestor"
+	WarnUnmetDependencies  = "unmet-dependencies"
+	WarnPendingChildren    = "pending-children"
+	WarnEmptyTask          = "empty-task"
+	WarnWorkingCopyDiff    = "working-copy-diff"
+	WarnUncheckedChecklist = "unchecked-checklist"
+	WarnWipParent          = "wip-parent"
+)
+
+var warningCodes = []string{
+	WarnExistingWip, WarnDoneAncestor, WarnBlockedAncestor, WarnUnmetDependencies,
+	WarnPendingChildren, WarnEmptyTask, WarnWorkingCopyDiff, WarnUncheckedChecklist, WarnWipParent,
+}
+
+// Warning severities
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+var (
+	strict bool // --strict: treat warnings as errors
+
+	// reportedWarnings collects the warnings shown during this invocation
+	reportedWarnings []Warning
+	// quietWarnings collects warnings without printing them (--format json)
+	quietWarnings bool
+)
+
+// Warning is a problem a command noticed but did not stop for
+type Warning struct {
+	Code        string   `json:"code"`
+	Severity    string   `json:"severity"`
+	Message     string   `json:"message"`
+	Revisions   []string `json:"revisions,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+
+	body   []string // text lines printed after the message
+	inline bool     // printed without surrounding blank lines
+}
+
+// render writes w the way it appears on stderr
+func (w Warning) render(out io.Writer) {
+	prefix := "⚠️  "
+	if w.Severity == SeverityInfo {
+		prefix = "Note: "
+	}
+	if !w.inline {
+		_, _ = fmt.Fprintln(out)
+	}
+	_, _ = fmt.Fprintln(out, prefix+w.Message)
+	for _, line := range w.body {
+		_, _ = fmt.Fprintln(out, line)
+	}
+	if !w.inline {
+		_, _ = fmt.Fprintln(out)
+	}
+}
+
+// warn reports warnings after applying the [warnings] policy and --strict.
+// Ignored codes are dropped; it returns an error if any warning was
+// escalated, so callers stop before changing anything.
+func warn(cmd *cobra.Command, warnings ...*Warning) error {
+	cfg, err := config.GetWarningsConfig()
+	if err != nil {
+		return err
+	}
+	for _, code := range append(slices.Clone(cfg.Ignore), cfg.Error...) {
+		if !slices.Contains(warningCodes, code) {
+			return fmt.Errorf("[warnings] unknown code %q, must be one of: %s", code, strings.Join(warningCodes, ", "))
+		}
+	}
+
+	var escalated []string
+	for _, w := range warnings {
+		if w == nil || slices.Contains(cfg.Ignore, w.Code) {
+			continue
+		}
+		if slices.Contains(cfg.Error, w.Code) || (strict && w.Severity == SeverityWarning) {
+			w.Severity = SeverityError
+			escalated = append(escalated, w.Code)
+		}
+		reportedWarnings = append(reportedWarnings, *w)
+		if !quietWarnings {
+			w.render(cmd.ErrOrStderr())
+		}
+	}
+	if len(escalated) > 0 {
+		return fmt.Errorf("warnings treated as errors: %s", strings.Join(escalated, ", "))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
+}
@@ -2,9 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
+
+	"jjtask/internal/task"
 )
 
 var wipCmd = &cobra.Command{
@@ -18,7 +19,8 @@ Work directly in task branches with 'jj edit TASK'.
 Examples:
   jjtask wip xyz       # Mark xyz as WIP, add to @ merge
   jjtask wip           # Mark @ as WIP (if it's a task)
-  jjtask wip a b c     # Mark multiple tasks as WIP`,
+  jjtask wip a b c     # Mark multiple tasks as WIP
+  jjtask wip api:xqq   # Mark xqq in the api repo as WIP (multi-repo)`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		revs := args
@@ -26,17 +28,28 @@ Examples:
 			revs = []string{"@"}
 		}
 
-		// Collect change IDs and mark all as WIP first
+		return startTasks(revs)
+	},
+}
+
+// startTasks marks tasks WIP and adds them as parents of @
+func startTasks(revs []string) error {
+	// Collect change IDs and mark all as WIP first
+	g, err := taskGraph()
+	if err != nil {
+		return fmt.Errorf("loading tasks: %w", err)
+	}
+
+	return client.Transaction(func() error {
 		var changeIDs []string
 		for _, rev := range revs {
-			changeID, err := client.Query("log", "-r", rev, "--no-graph", "-T", "change_id.shortest()")
+			t, err := g.Resolve(rev)
 			if err != nil {
 				return fmt.Errorf("getting change ID for %s: %w", rev, err)
 			}
-			changeID = strings.TrimSpace(changeID)
-			changeIDs = append(changeIDs, changeID)
+			changeIDs = append(changeIDs, t.ChangeID)
 
-			if err := setTaskFlag(rev, "wip"); err != nil {
+			if err := client.SetDescription(t.ID, task.SetFlag(t.Description, "wip")); err != nil {
 				return fmt.Errorf("failed to mark %s as WIP: %w", rev, err)
 			}
 		}
@@ -47,9 +60,11 @@ Examples:
 		}
 
 		return nil
-	},
+	})
 }
 
 func init() {
+	withResultFormat(wipCmd)
+	withRepoRefs(wipCmd)
 	rootCmd.AddCommand(wipCmd)
 }
@@ -76,9 +76,9 @@ uninstall() {
     echo "  Removed: $bash_wrapper"
   fi
 
-  # Remove jj config symlink
+  # Remove jj config (symlink or generated by 'jjtask config install')
   local jj_config="$JJ_CONFIG_DIR/conf.d/10-jjtask.toml"
-  if [[ -L "$jj_config" ]]; then
+  if [[ -L "$jj_config" ]] || grep -q "^# jjtask base config" "$jj_config" 2>/dev/null; then
     rm "$jj_config"
     echo "  Removed: $jj_config"
   fi
@@ -224,6 +224,14 @@ install_jj_config() {
   local conf_d="$JJ_CONFIG_DIR/conf.d"
   mkdir -p "$conf_d"
 
+  local binary="$SCRIPT_DIR/bin/jjtask-go"
+  if [[ -x "$binary" ]]; then
+    if "$binary" config install --dir "$conf_d" | sed 's/^/  /'; then
+      return
+    fi
+    echo "  Falling back to symlinking the checked-in config"
+  fi
+
   local src="$SCRIPT_DIR/config/conf.d/10-jjtask.toml"
   local dst="$conf_d/10-jjtask.toml"
 
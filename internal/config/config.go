@@ -11,13 +11,21 @@ import (
 
 // Config represents .jjtask.toml
 type Config struct {
-	Workspaces WorkspacesConfig `toml:"workspaces"`
-	Prime      PrimeConfig      `toml:"prime"`
+	Workspaces WorkspacesConfig  `toml:"workspaces"`
+	Prime      PrimeConfig       `toml:"prime"`
+	Done       DoneConfig        `toml:"done"`
+	Warnings   WarningsConfig    `toml:"warnings"`
+	Statuses   StatusesConfig    `toml:"statuses"`
+	Scope      ScopeConfig       `toml:"scope"`
+	Metadata   MetadataConfig    `toml:"metadata"`
+	Revsets    map[string]string `toml:"revsets"` // jj revset aliases replacing jjtask's, e.g. "tasks()"
 }
 
 // WorkspacesConfig holds multi-repo workspace configuration
 type WorkspacesConfig struct {
-	Repos []Repo `toml:"repos"`
+	Repos   []Repo `toml:"repos"`
+	Jobs    int    `toml:"jobs"`    // repos queried at once
+	Timeout string `toml:"timeout"` // per-repo limit, e.g. "5s"
 }
 
 // Repo represents a single repo in the config
@@ -32,6 +40,44 @@ type PrimeConfig struct {
 	ContentFile string `toml:"content_file"`
 }
 
+// DoneConfig holds checks run by 'jjtask done'
+type DoneConfig struct {
+	Verify []string `toml:"verify"`  // shell commands that must pass before marking done
+	OnFail string   `toml:"on_fail"` // status flagged when verification fails
+}
+
+// WarningsConfig adjusts how warning codes are reported
+type WarningsConfig struct {
+	Ignore []string `toml:"ignore"` // codes that are never shown
+	Error  []string `toml:"error"`  // codes that fail the command
+}
+
+// StatusesConfig extends the task status vocabulary
+type StatusesConfig struct {
+	Custom      []CustomStatus      `toml:"custom"`
+	Transitions map[string][]string `toml:"transitions"` // allowed targets per status; unlisted statuses allow any
+}
+
+// CustomStatus is a [[statuses.custom]] entry
+type CustomStatus struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"` // shown in completion
+	Color       string `toml:"color"`       // jj color name, e.g. "magenta"
+	Done        bool   `toml:"done"`        // counts as complete rather than pending
+}
+
+// ScopeConfig sets which revisions tasks() searches; at most one field is set
+type ScopeConfig struct {
+	Depth int    `toml:"depth"` // ancestors of @ whose descendants are searched (default 200)
+	Base  string `toml:"base"`  // revset whose descendants are searched, e.g. "trunk()"
+	All   bool   `toml:"all"`   // search all visible revisions
+}
+
+// MetadataConfig registers custom trailer keys
+type MetadataConfig struct {
+	Keys []string `toml:"keys"` // read back as trailers alongside the built-in keys, e.g. "Reviewer"
+}
+
 var configRoot string
 var loadedConfig *Config
 
@@ -116,6 +162,15 @@ func GetRepos() ([]Repo, string, error) {
 	return cfg.Workspaces.Repos, root, nil
 }
 
+// GetWorkspacesConfig returns the [workspaces] section
+func GetWorkspacesConfig() (WorkspacesConfig, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return WorkspacesConfig{}, err
+	}
+	return cfg.Workspaces, nil
+}
+
 // IsMultiRepo returns true if multi-repo config exists
 func IsMultiRepo() bool {
 	cfg, _, _ := Load()
@@ -154,6 +209,71 @@ func GetPrimeContent() (content string, hasCustom bool, err error) {
 	return "", false, nil
 }
 
+// GetDoneConfig returns the [done] section, with OnFail defaulting to untested
+func GetDoneConfig() (DoneConfig, error) {
+	cfg, _, err := Load()
+	if err != nil {
+		return DoneConfig{}, err
+	}
+	var done DoneConfig
+	if cfg != nil {
+		done = cfg.Done
+	}
+	switch done.OnFail {
+	case "":
+		done.OnFail = "untested"
+	case "untested", "review":
+	default:
+		return DoneConfig{}, fmt.Errorf("[done] on_fail must be untested or review, got %q", done.OnFail)
+	}
+	return done, nil
+}
+
+// GetWarningsConfig returns the [warnings] section
+func GetWarningsConfig() (WarningsConfig, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return WarningsConfig{}, err
+	}
+	return cfg.Warnings, nil
+}
+
+// GetStatusesConfig returns the [statuses] section
+func GetStatusesConfig() (StatusesConfig, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return StatusesConfig{}, err
+	}
+	return cfg.Statuses, nil
+}
+
+// GetRevsets returns the [revsets] alias overrides
+func GetRevsets() (map[string]string, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return cfg.Revsets, nil
+}
+
+// GetScopeConfig returns the [scope] section
+func GetScopeConfig() (ScopeConfig, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return ScopeConfig{}, err
+	}
+	return cfg.Scope, nil
+}
+
+// GetMetadataConfig returns the [metadata] section
+func GetMetadataConfig() (MetadataConfig, error) {
+	cfg, _, err := Load()
+	if err != nil || cfg == nil {
+		return MetadataConfig{}, err
+	}
+	return cfg.Metadata, nil
+}
+
 // Reset clears cached config (for testing)
 func Reset() {
 	loadedConfig = nil
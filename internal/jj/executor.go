@@ -0,0 +1,48 @@
+package jj
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Invocation describes a single jj process: its full argument list (global
+// flags included) and where its standard streams are connected
+type Invocation struct {
+	Context context.Context // cancels the process; nil means no deadline
+
+	Args   []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Executor runs jj invocations. Every jj call made by a Client goes through
+// its Executor, so tests can substitute a scripted fake for the jj binary.
+type Executor interface {
+	Execute(inv Invocation) error
+}
+
+// BinaryExecutor runs the jj binary found on PATH
+type BinaryExecutor struct{}
+
+// Execute runs jj as a subprocess with jjtask's environment
+func (BinaryExecutor) Execute(inv Invocation) error {
+	ctx := inv.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd := exec.CommandContext(ctx, "jj", inv.Args...)
+	cmd.Stdin = inv.Stdin
+	cmd.Stdout = inv.Stdout
+	cmd.Stderr = inv.Stderr
+	cmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
+	if err := cmd.Run(); ctx.Err() != nil {
+		return fmt.Errorf("jj %w", ctx.Err())
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
@@ -2,9 +2,10 @@ package jj
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -28,23 +29,79 @@ type GlobalFlags struct {
 
 // Client wraps jj subprocess calls
 type Client struct {
-	Globals GlobalFlags
-	IsTTY   bool
+	Globals  GlobalFlags
+	IsTTY    bool
+	DryRun   bool     // print mutating commands instead of running them
+	Executor Executor // runs jj processes; nil means the jj binary
+
+	ctx            context.Context // passed to every invocation
+	stdout, stderr io.Writer       // where Run and Pipe write; nil means os.Stdout/os.Stderr
+
+	planned       [][]string // mutating commands skipped in dry-run mode
+	generation    int        // bumped on every potentially mutating call
+	inTransaction bool       // nested transactions join the outer one
 }
 
 // New creates a jj client with default settings
 func New() *Client {
 	return &Client{
-		IsTTY: isTerminal(),
+		IsTTY:    isTerminal(),
+		Executor: BinaryExecutor{},
 	}
 }
 
 // NewWithGlobals creates a jj client with parsed global flags
 func NewWithGlobals(globals GlobalFlags) *Client {
 	return &Client{
-		Globals: globals,
-		IsTTY:   isTerminal(),
+		Globals:  globals,
+		IsTTY:    isTerminal(),
+		Executor: BinaryExecutor{},
+	}
+}
+
+// NewWithExecutor creates a jj client that runs jj through e
+func NewWithExecutor(e Executor) *Client {
+	return &Client{
+		Executor: e,
+	}
+}
+
+// ForRepo returns a copy of the client targeting another repository
+func (c *Client) ForRepo(path string) *Client {
+	clone := *c
+	clone.Globals.Repository = path
+	return &clone
+}
+
+// WithContext returns a copy of the client whose jj processes are killed
+// when ctx is done
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// WithOutput returns a copy of the client whose Run and Pipe write to
+// stdout and stderr instead of the terminal, with no stdin
+func (c *Client) WithOutput(stdout, stderr io.Writer) *Client {
+	clone := *c
+	clone.stdout, clone.stderr = stdout, stderr
+	return &clone
+}
+
+// streams returns the stdin, stdout and stderr for Run
+func (c *Client) streams() (io.Reader, io.Writer, io.Writer) {
+	if c.stdout == nil {
+		return os.Stdin, os.Stdout, os.Stderr
+	}
+	return nil, c.stdout, c.stderr
+}
+
+func (c *Client) executor() Executor {
+	if c.Executor == nil {
+		return BinaryExecutor{}
 	}
+	return c.Executor
 }
 
 func isTerminal() bool {
@@ -118,25 +175,91 @@ func (c *Client) buildArgs(args []string) []string {
 	return append(result, args...)
 }
 
+// Generation returns a counter that changes whenever the client ran a
+// potentially mutating command, so cached repo state can be invalidated
+func (c *Client) Generation() int {
+	return c.generation
+}
+
+// Planned returns the mutating commands recorded in dry-run mode
+func (c *Client) Planned() [][]string {
+	return c.planned
+}
+
+// plan records a mutating command instead of executing it, printing it to
+// the client's stderr
+func (c *Client) plan(input string, args []string) {
+	if c.Globals.Repository != "" {
+		args = append([]string{"-R", c.Globals.Repository}, args...)
+	}
+	c.planned = append(c.planned, args)
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	// Plans go to stderr so they stay out of JSON output
+	_, _, w := c.streams()
+	_, _ = fmt.Fprintf(w, "[dry-run] jj %s\n", strings.Join(quoted, " "))
+	if input != "" {
+		for line := range strings.SplitSeq(strings.TrimRight(input, "\n"), "\n") {
+			_, _ = fmt.Fprintf(w, "[dry-run]   | %s\n", line)
+		}
+	}
+}
+
+// shellQuote quotes arg for display when it contains shell metacharacters
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;()<>*?[]{}~!#") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
 // Run executes jj with given args, inheriting stdin/stdout/stderr
 func (c *Client) Run(args ...string) error {
-	cmd := exec.Command("jj", c.buildArgs(args)...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
-	return cmd.Run()
+	if c.DryRun {
+		c.plan("", args)
+		return nil
+	}
+	c.generation++
+	stdin, stdout, stderr := c.streams()
+	return c.executor().Execute(Invocation{
+		Context: c.ctx,
+		Args:    c.buildArgs(args),
+		Stdin:   stdin,
+		Stdout:  stdout,
+		Stderr:  stderr,
+	})
 }
 
 // Query executes jj for internal queries (with --ignore-working-copy, --color=never)
 func (c *Client) Query(args ...string) (string, error) {
-	queryArgs := append([]string{"--ignore-working-copy", "--color=never"}, args...)
-	cmd := exec.Command("jj", c.buildQueryArgs(queryArgs)...)
-	cmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
+	return c.query(append([]string{"--ignore-working-copy", "--color=never"}, args...))
+}
+
+// query executes a read-only jj command, capturing stdout
+func (c *Client) query(queryArgs []string) (string, error) {
+	return c.capture(nil, c.buildQueryArgs(queryArgs))
+}
+
+// Output executes a read-only jj command with user-facing flags (color
+// included), capturing stdout for display
+func (c *Client) Output(args ...string) (string, error) {
+	return c.capture(nil, c.buildArgs(args))
+}
+
+// capture runs jj with fully built args, returning stdout and folding
+// stderr into the error
+func (c *Client) capture(stdin io.Reader, args []string) (string, error) {
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	err := cmd.Run()
+	err := c.executor().Execute(Invocation{
+		Context: c.ctx,
+		Args:    args,
+		Stdin:   stdin,
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+	})
 	if err != nil {
 		if stderr.Len() > 0 {
 			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
@@ -148,36 +271,36 @@ func (c *Client) Query(args ...string) (string, error) {
 
 // Pipe executes jj with stdin from input string
 func (c *Client) Pipe(input string, args ...string) error {
-	cmd := exec.Command("jj", c.buildArgs(args)...)
-	cmd.Stdin = strings.NewReader(input)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
-	return cmd.Run()
+	if c.DryRun {
+		c.plan(input, args)
+		return nil
+	}
+	c.generation++
+	_, stdout, stderr := c.streams()
+	return c.executor().Execute(Invocation{
+		Context: c.ctx,
+		Args:    c.buildArgs(args),
+		Stdin:   strings.NewReader(input),
+		Stdout:  stdout,
+		Stderr:  stderr,
+	})
 }
 
 // PipeQuiet executes jj with stdin, capturing output
 func (c *Client) PipeQuiet(input string, args ...string) (string, error) {
-	cmd := exec.Command("jj", c.buildArgs(args)...)
-	cmd.Stdin = strings.NewReader(input)
-	cmd.Env = append(os.Environ(), "JJ_ALLOW_TASK=1", "JJ_NO_HINTS=1")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	err := cmd.Run()
-	if err != nil {
-		if stderr.Len() > 0 {
-			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
-		}
-		return "", err
+	if c.DryRun {
+		c.plan(input, args)
+		return "", nil
 	}
-	return stdout.String(), nil
+	c.generation++
+	return c.capture(strings.NewReader(input), c.buildArgs(args))
 }
 
 // Root returns the repository root directory
 func (c *Client) Root() (string, error) {
-	// Prefer JJ_WORKSPACE_ROOT from jj util exec
-	if root := os.Getenv("JJ_WORKSPACE_ROOT"); root != "" {
+	// Prefer JJ_WORKSPACE_ROOT from jj util exec, which names the repo jj
+	// was started in rather than one picked with -R
+	if root := os.Getenv("JJ_WORKSPACE_ROOT"); root != "" && c.Globals.Repository == "" {
 		return root, nil
 	}
 	out, err := c.Query("root")
@@ -207,7 +330,24 @@ func (c *Client) IsValidRev(rev string) bool {
 	return err == nil
 }
 
-// FindConfigDir locates jjtask config directory
+// ConfigFileName is the name of jjtask's file in jj's conf.d
+const ConfigFileName = "10-jjtask.toml"
+
+// UserConfDir returns the conf.d directory of the user's jj config
+func UserConfDir() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "jj", "conf.d")
+}
+
+// FindConfigDir locates jjtask config directory. Only needed when the
+// aliases are not installed with 'jjtask config install'.
 func FindConfigDir() string {
 	// Find the jjtask installation directory
 	exe, err := os.Executable()
@@ -233,14 +373,25 @@ func SetupEnv() {
 	_ = os.Setenv("JJ_ALLOW_TASK", "1")
 	_ = os.Setenv("JJ_NO_HINTS", "1")
 
-	// Auto-set JJ_CONFIG for agent mode (non-TTY)
-	if os.Getenv("JJ_CONFIG") == "" && !isTerminal() {
+	// Auto-set JJ_CONFIG for agent mode (non-TTY), unless the aliases are
+	// installed in the user's config, which JJ_CONFIG would hide
+	if os.Getenv("JJ_CONFIG") == "" && !isTerminal() && !configInstalled() {
 		if confDir := FindConfigDir(); confDir != "" {
 			_ = os.Setenv("JJ_CONFIG", confDir)
 		}
 	}
 }
 
+// configInstalled reports whether 'jjtask config install' has run
+func configInstalled() bool {
+	dir := UserConfDir()
+	if dir == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(dir, ConfigFileName))
+	return err == nil
+}
+
 // GetActiveRevisions returns change IDs of WIP tasks only
 func (c *Client) GetActiveRevisions() ([]string, error) {
 	out, err := c.Query("log", "-r", "tasks_wip()", "--no-graph", "-T", `change_id.shortest() ++ "\n"`)
@@ -279,6 +430,12 @@ func (c *Client) GetParents(rev string) ([]string, error) {
 
 // RemoveFromMerge removes a revision from @'s parents, preserving @ content
 func (c *Client) RemoveFromMerge(task string) error {
+	return c.Transaction(func() error {
+		return c.removeFromMerge(task)
+	})
+}
+
+func (c *Client) removeFromMerge(task string) error {
 	parents, err := c.GetParents("@")
 	if err != nil {
 		return fmt.Errorf("getting parents: %w", err)
@@ -332,6 +489,12 @@ func (c *Client) AddMultipleToMerge(tasks []string) error {
 		return nil
 	}
 
+	return c.Transaction(func() error {
+		return c.addMultipleToMerge(tasks)
+	})
+}
+
+func (c *Client) addMultipleToMerge(tasks []string) error {
 	atID, err := c.Query("log", "-r", "@", "--no-graph", "-T", "change_id.shortest()")
 	if err != nil {
 		return fmt.Errorf("getting @ ID: %w", err)
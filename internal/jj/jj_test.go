@@ -0,0 +1,180 @@
+package jj_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"jjtask/internal/jj/jjtest"
+)
+
+func TestAddMultipleToMergeSingleRebase(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log").Returns("op1\n")
+	fake.On("log", "-r", "@").Returns("at")
+	fake.On("log", "-r", "parents(@)").Returns("a\n")
+	fake.On("rebase")
+
+	if err := fake.Client().AddMultipleToMerge([]string{"b", "at", "a", "c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"rebase -r @ -o a -o b -o c"}
+	if got := fake.Commands("rebase"); !slices.Equal(got, want) {
+		t.Errorf("rebases = %q, want %q", got, want)
+	}
+}
+
+func TestAddMultipleToMergeAlreadyParents(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log").Returns("op1\n")
+	fake.On("log", "-r", "@").Returns("at")
+	fake.On("log", "-r", "parents(@)").Returns("a\nb\n")
+
+	if err := fake.Client().AddMultipleToMerge([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Commands("rebase"); len(got) != 0 {
+		t.Errorf("expected no rebase, got %q", got)
+	}
+}
+
+func TestRemoveFromMerge(t *testing.T) {
+	tests := []struct {
+		parents string
+		want    []string
+	}{
+		{"a\nb\nc\n", []string{"rebase -r @ -o a -o c"}},
+		{"a\nb\n", []string{"squash --into a --keep-emptied", "edit a"}},
+		{"b\nzzzzzzzz\n", nil},
+	}
+
+	for _, tt := range tests {
+		fake := jjtest.New()
+		fake.On("op", "log").Returns("op1\n")
+		fake.On("log", "-r", "parents(@)").Returns(tt.parents)
+		fake.On("rebase")
+		fake.On("squash")
+		fake.On("edit")
+
+		if err := fake.Client().RemoveFromMerge("b"); err != nil {
+			t.Fatal(err)
+		}
+		if got := fake.Commands("rebase", "squash", "edit"); !slices.Equal(got, tt.want) {
+			t.Errorf("parents %q: commands = %q, want %q", tt.parents, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionRollsBackOnFailure(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log", "--no-graph", "-T", "id.short()").Returns("op1\n")
+	fake.On("op", "log").Returns("op3 rebase\nop2 describe\nop1 snapshot\n")
+	fake.On("describe")
+	fake.On("rebase").Fails("Error: conflict")
+	fake.On("op", "restore")
+
+	c := fake.Client()
+	err := c.Transaction(func() error {
+		if err := c.Pipe("[task:done] A\n", "describe", "-r", "a", "--stdin"); err != nil {
+			return err
+		}
+		return c.Run("rebase", "-s", "b", "-o", "a")
+	})
+	if err == nil || !strings.Contains(err.Error(), "rolled back to operation op1") {
+		t.Fatalf("err = %v, want rollback error", err)
+	}
+
+	want := []string{"describe -r a --stdin", "rebase -s b -o a"}
+	if got := fake.Commands("describe", "rebase"); !slices.Equal(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	calls := fake.Calls()
+	if last := calls[len(calls)-1].String(); last != "op restore op1" {
+		t.Errorf("last call = %q, want op restore op1", last)
+	}
+}
+
+func TestTransactionRestoresWhenOpLogFails(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log", "--no-graph", "-T", "id.short()").Returns("op1\n")
+	fake.On("op", "log").Fails("Error: lock held")
+	fake.On("rebase").Fails("Error: conflict")
+	fake.On("op", "restore")
+
+	c := fake.Client()
+	err := c.Transaction(func() error { return c.Run("rebase", "-s", "b", "-o", "a") })
+	if err == nil || !strings.Contains(err.Error(), "rolled back to operation op1") {
+		t.Fatalf("err = %v, want rollback error", err)
+	}
+	if got := fake.Commands("op"); !slices.Contains(got, "op restore op1") {
+		t.Errorf("op calls = %q, want a restore", got)
+	}
+}
+
+func TestTransactionPanicLeavesTransaction(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log").Returns("op1\n")
+	c := fake.Client()
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = c.Transaction(func() error { panic("boom") })
+	}()
+	if err := c.Transaction(func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Commands("op"); len(got) != 2 {
+		t.Errorf("transaction after a panic joined the dead one: op calls %q", got)
+	}
+}
+
+func TestTransactionNestedJoinsOuter(t *testing.T) {
+	fake := jjtest.New()
+	fake.On("op", "log").Returns("op1\n")
+	c := fake.Client()
+
+	err := c.Transaction(func() error {
+		return c.Transaction(func() error { return nil })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Commands("op"); len(got) != 1 {
+		t.Errorf("nested transaction recorded its own operation: %q", got)
+	}
+}
+
+func TestDryRunSkipsMutations(t *testing.T) {
+	fake := jjtest.New()
+	var stdout, stderr strings.Builder
+	c := fake.Client().WithOutput(&stdout, &stderr)
+	c.DryRun = true
+
+	if err := c.Run("rebase", "-s", "a", "-o", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() != 0 || stderr.String() != "[dry-run] jj rebase -s a -o b\n" {
+		t.Errorf("stdout %q, stderr %q; want the plan on stderr only", stdout.String(), stderr.String())
+	}
+	if got := fake.Calls(); len(got) != 0 {
+		t.Errorf("dry run executed %v", got)
+	}
+	if got := c.Planned(); len(got) != 1 || got[0][0] != "rebase" {
+		t.Errorf("planned = %v", got)
+	}
+}
+
+func TestRootForRepoIgnoresWorkspaceEnv(t *testing.T) {
+	t.Setenv("JJ_WORKSPACE_ROOT", "/ws/web")
+	fake := jjtest.New()
+	fake.On("root").Returns("/ws/api\n")
+	c := fake.Client()
+
+	if root, err := c.Root(); err != nil || root != "/ws/web" {
+		t.Errorf("Root() = %q, %v; want /ws/web from the environment", root, err)
+	}
+	if root, err := c.ForRepo("/ws/api").Root(); err != nil || root != "/ws/api" {
+		t.Errorf("ForRepo Root() = %q, %v; want /ws/api from jj", root, err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package jjtest provides a scripted jj executor for unit tests that run
+// without a jj binary.
+package jjtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"jjtask/internal/jj"
+)
+
+// Call is one recorded jj invocation with global flags stripped
+type Call struct {
+	Args  []string
+	Stdin string
+}
+
+// String formats the call like a command line, e.g. "rebase -s a -o b"
+func (c Call) String() string {
+	return strings.Join(c.Args, " ")
+}
+
+// Response is a scripted reply to calls starting with a given argument prefix
+type Response struct {
+	prefix []string
+	stdout string
+	stderr string
+	err    error
+	once   bool
+	used   bool
+}
+
+// Returns sets the stdout written for matching calls
+func (r *Response) Returns(stdout string) *Response {
+	r.stdout = stdout
+	return r
+}
+
+// Fails makes matching calls exit with an error and the given stderr
+func (r *Response) Fails(stderr string) *Response {
+	r.stderr = strings.TrimSuffix(stderr, "\n") + "\n"
+	r.err = errors.New("exit status 1")
+	return r
+}
+
+// Once limits the response to the first matching call, so later calls fall
+// through to responses registered after it
+func (r *Response) Once() *Response {
+	r.once = true
+	return r
+}
+
+// Fake is a jj.Executor that replays scripted responses and records every
+// call it receives. Calls without a matching response fail the invocation.
+type Fake struct {
+	mu        sync.Mutex
+	responses []*Response
+	calls     []Call
+}
+
+// New creates an empty fake
+func New() *Fake {
+	return &Fake{}
+}
+
+// Client returns a jj client that runs every command through the fake
+func (f *Fake) Client() *jj.Client {
+	return jj.NewWithExecutor(f)
+}
+
+// On registers a response for calls whose arguments (after global flags)
+// start with prefix. Responses are matched in registration order.
+func (f *Fake) On(prefix ...string) *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &Response{prefix: prefix}
+	f.responses = append(f.responses, r)
+	return r
+}
+
+// Calls returns every recorded call in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+// Commands returns recorded calls whose subcommand is one of names,
+// formatted as command lines. With no names, all calls are returned.
+func (f *Fake) Commands(names ...string) []string {
+	var cmds []string
+	for _, c := range f.Calls() {
+		if len(names) == 0 || (len(c.Args) > 0 && slices.Contains(names, c.Args[0])) {
+			cmds = append(cmds, c.String())
+		}
+	}
+	return cmds
+}
+
+// Execute records inv and replays the first matching response
+func (f *Fake) Execute(inv jj.Invocation) error {
+	call := Call{Args: StripGlobals(inv.Args)}
+	if inv.Stdin != nil {
+		if data, err := io.ReadAll(inv.Stdin); err == nil {
+			call.Stdin = string(data)
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	var match *Response
+	for _, r := range f.responses {
+		if r.once && r.used {
+			continue
+		}
+		if len(call.Args) >= len(r.prefix) && slices.Equal(call.Args[:len(r.prefix)], r.prefix) {
+			match = r
+			r.used = true
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	if match == nil {
+		if inv.Stderr != nil {
+			_, _ = fmt.Fprintf(inv.Stderr, "jjtest: unexpected call: jj %s\n", call)
+		}
+		return fmt.Errorf("jjtest: unexpected call: jj %s", call)
+	}
+	if inv.Stdout != nil && match.stdout != "" {
+		_, _ = io.WriteString(inv.Stdout, match.stdout)
+	}
+	if inv.Stderr != nil && match.stderr != "" {
+		_, _ = io.WriteString(inv.Stderr, match.stderr)
+	}
+	return match.err
+}
+
+// globalsWithValue are jj global flags followed by a separate value
+var globalsWithValue = []string{"-R", "--repository", "--at-operation", "--color", "--config", "--config-file"}
+
+// StripGlobals removes the leading jj global flags that Client adds, leaving
+// the subcommand and its arguments
+func StripGlobals(args []string) []string {
+	i := 0
+	for i < len(args) {
+		arg := args[i]
+		switch {
+		case slices.Contains(globalsWithValue, arg):
+			i += 2
+		case strings.HasPrefix(arg, "--color="),
+			arg == "--ignore-working-copy",
+			arg == "--ignore-immutable",
+			arg == "--debug",
+			arg == "--quiet",
+			arg == "--no-pager":
+			i++
+		default:
+			return args[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package jj
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transaction runs fn and, if it fails, restores the repo to the jj operation
+// that was current before fn started. Nested transactions join the outer one,
+// so only the outermost failure triggers a restore.
+func (c *Client) Transaction(fn func() error) error {
+	// Dry runs change nothing, so there is nothing to roll back
+	if c.inTransaction || c.DryRun {
+		return fn()
+	}
+
+	startOp, err := c.CurrentOperation()
+	if err != nil {
+		return fmt.Errorf("recording starting operation: %w", err)
+	}
+
+	err = c.inTx(fn)
+	if err == nil {
+		return nil
+	}
+
+	// When the op log cannot be read, restore anyway: restoring to the
+	// current operation is a no-op, skipping it could leave a half-applied
+	// rewrite behind
+	undone, opsErr := c.operationsSince(startOp)
+	if opsErr == nil && len(undone) == 0 {
+		return err
+	}
+	if restoreErr := c.Run("op", "restore", startOp); restoreErr != nil {
+		return fmt.Errorf("%w (rollback to operation %s failed: %v; restore manually with: jj op restore %s)", err, startOp, restoreErr, startOp)
+	}
+
+	_, _, stderr := c.streams()
+	if opsErr != nil {
+		_, _ = fmt.Fprintf(stderr, "Rolled back to operation %s (listing undone operations failed: %v)\n", startOp, opsErr)
+	} else {
+		_, _ = fmt.Fprintf(stderr, "Rolled back to operation %s, undoing %d operation(s):\n", startOp, len(undone))
+		for _, op := range undone {
+			_, _ = fmt.Fprintf(stderr, "  • %s\n", op)
+		}
+	}
+	return fmt.Errorf("%w (rolled back to operation %s)", err, startOp)
+}
+
+// inTx runs fn as the body of a transaction, leaving the transaction even
+// if fn panics
+func (c *Client) inTx(fn func() error) error {
+	c.inTransaction = true
+	defer func() { c.inTransaction = false }()
+	return fn()
+}
+
+// CurrentOperation returns the current operation ID after snapshotting the
+// working copy, so restoring to it never discards file changes
+func (c *Client) CurrentOperation() (string, error) {
+	out, err := c.query([]string{"--color=never", "op", "log", "--no-graph", "-T", "id.short()", "--limit", "1"})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// operationsSince lists operations (newest first) recorded after opID
+func (c *Client) operationsSince(opID string) ([]string, error) {
+	out, err := c.Query("op", "log", "--no-graph", "-T", `id.short() ++ " " ++ description.first_line() ++ "\n"`, "--limit", "100")
+	if err != nil {
+		return nil, err
+	}
+	var ops []string
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, opID) {
+			return ops, nil
+		}
+		if line != "" {
+			ops = append(ops, line)
+		}
+	}
+	return ops, nil
+}
@@ -0,0 +1,257 @@
+// Package mcp implements a minimal Model Context Protocol server: JSON-RPC
+// 2.0 messages, one per line, over stdio, with tools and resources.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ProtocolVersions lists supported MCP revisions, newest first
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Tool is a callable operation. Handler receives the raw arguments object
+// and returns a JSON-encodable object, which is sent as structured content.
+type Tool struct {
+	Name        string                                  `json:"name"`
+	Description string                                  `json:"description"`
+	InputSchema map[string]any                          `json:"inputSchema"`
+	Handler     func(args json.RawMessage) (any, error) `json:"-"`
+}
+
+// Resource is a readable document
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes a family of resources by URI template
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the body of a read resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Server dispatches MCP requests to registered tools and resources
+type Server struct {
+	Name    string
+	Version string
+
+	Tools     []Tool
+	Templates []ResourceTemplate
+	// ListResources returns the currently available resources
+	ListResources func() ([]Resource, error)
+	// ReadResource returns the contents of uri
+	ReadResource func(uri string) (ResourceContents, error)
+	// Refresh, if set, runs before each tools/call and resources/* request,
+	// e.g. to drop state cached by an earlier request
+	Refresh func()
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve handles newline-delimited requests from r until EOF, writing
+// responses to w. Requests are handled one at a time.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handle(line); resp != nil {
+			if err := enc.Encode(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// handle processes one message, returning nil for notifications
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}}
+	}
+	if req.ID == nil {
+		return nil // notification
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{codeInvalidRequest, "invalid request"}
+		return resp
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+// call dispatches a request, turning a panic in a handler into an error
+// response so one bad request does not end the session
+func (s *Server) call(method string, params json.RawMessage) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, &rpcError{codeInternalError, fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+	if s.Refresh != nil && (method == "tools/call" || strings.HasPrefix(method, "resources/")) {
+		s.Refresh()
+	}
+	return s.dispatch(method, params)
+}
+
+func (s *Server) dispatch(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(params, &p)
+		version := ProtocolVersions[0]
+		if slices.Contains(ProtocolVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": s.Name, "version": s.Version},
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "tools/list":
+		return map[string]any{"tools": s.Tools}, nil
+
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(s.Tools, func(t Tool) bool { return t.Name == p.Name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown tool %q", p.Name)
+		}
+		if len(p.Arguments) == 0 {
+			p.Arguments = json.RawMessage("{}")
+		}
+		return toolResult(s.Tools[i].Handler(p.Arguments)), nil
+
+	case "resources/list":
+		resources := []Resource{}
+		if s.ListResources != nil {
+			list, err := s.ListResources()
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, list...)
+		}
+		return map[string]any{"resources": resources}, nil
+
+	case "resources/templates/list":
+		templates := s.Templates
+		if templates == nil {
+			templates = []ResourceTemplate{}
+		}
+		return map[string]any{"resourceTemplates": templates}, nil
+
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		if s.ReadResource == nil {
+			return nil, fmt.Errorf("unknown resource %q", p.URI)
+		}
+		contents, err := s.ReadResource(p.URI)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"contents": []ResourceContents{contents}}, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, "method not found: " + method}
+}
+
+// toolResult wraps a handler's result. Failures are reported in the result
+// with isError so the model can see and react to them.
+func toolResult(result any, err error) map[string]any {
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return toolResult(nil, err)
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(data)}},
+		"structuredContent": result,
+		"isError":           false,
+	}
+}
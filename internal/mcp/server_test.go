@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, s *Server, requests ...string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var responses []map[string]any
+	dec := json.NewDecoder(strings.NewReader(out.String()))
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServe(t *testing.T) {
+	s := &Server{
+		Name:    "test",
+		Version: "1",
+		Tools: []Tool{{
+			Name:        "echo",
+			InputSchema: map[string]any{"type": "object"},
+			Handler: func(args json.RawMessage) (any, error) {
+				var p struct{ Text string }
+				if err := json.Unmarshal(args, &p); err != nil {
+					return nil, err
+				}
+				if p.Text == "" {
+					return nil, errors.New("text is required")
+				}
+				return map[string]string{"text": p.Text}, nil
+			},
+		}},
+		ReadResource: func(uri string) (ResourceContents, error) {
+			return ResourceContents{URI: uri, Text: "body"}, nil
+		},
+	}
+
+	responses := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":"r","method":"resources/read","params":{"uri":"x://y"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"nope"}`,
+	)
+	if len(responses) != 5 {
+		t.Fatalf("got %d responses, want 5 (notifications get none)", len(responses))
+	}
+
+	if v := responses[0]["result"].(map[string]any)["protocolVersion"]; v != "2024-11-05" {
+		t.Errorf("protocolVersion = %v, want the client's 2024-11-05", v)
+	}
+	ok := responses[1]["result"].(map[string]any)
+	if ok["isError"] != false || ok["structuredContent"].(map[string]any)["text"] != "hi" {
+		t.Errorf("echo result = %v", ok)
+	}
+	failed := responses[2]["result"].(map[string]any)
+	if failed["isError"] != true || !strings.Contains(failed["content"].([]any)[0].(map[string]any)["text"].(string), "required") {
+		t.Errorf("failed tool result = %v", failed)
+	}
+	if responses[3]["id"] != "r" {
+		t.Errorf("string id not echoed: %v", responses[3]["id"])
+	}
+	if code := responses[4]["error"].(map[string]any)["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("unknown method code = %v", code)
+	}
+}
+
+func TestServeRecoversAndRefreshes(t *testing.T) {
+	refreshed := 0
+	s := &Server{
+		Tools: []Tool{{
+			Name: "boom",
+			Handler: func(json.RawMessage) (any, error) {
+				panic("nil graph")
+			},
+		}},
+		Refresh: func() { refreshed++ },
+	}
+
+	responses := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"boom"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3: the panic must not end the session", len(responses))
+	}
+	rpcErr := responses[0]["error"].(map[string]any)
+	if rpcErr["code"] != float64(codeInternalError) || !strings.Contains(rpcErr["message"].(string), "nil graph") {
+		t.Errorf("panic response = %v", rpcErr)
+	}
+	if refreshed != 2 {
+		t.Errorf("Refresh ran %d times, want 2 (tools/call and resources/list)", refreshed)
+	}
+}
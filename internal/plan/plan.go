@@ -0,0 +1,263 @@
+// Package plan reads and writes declarative task plans: tasks with local
+// keys, parent edges and trailers that 'jjtask plan apply' turns into a
+// task DAG.
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+
+	"jjtask/internal/task"
+)
+
+// Supported plan file formats
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatJSON = "json"
+)
+
+// Plan is a set of tasks to create or update
+type Plan struct {
+	Parent string `yaml:"parent,omitempty" toml:"parent,omitempty" json:"parent,omitempty"` // revision for tasks without parents (default @)
+	Tasks  []Task `yaml:"tasks" toml:"tasks" json:"tasks"`
+}
+
+// Task is one task in a plan
+type Task struct {
+	Key       string         `yaml:"key" toml:"key" json:"key"`                                                    // stable identifier, stored as a Task-Key trailer
+	Title     string         `yaml:"title" toml:"title" json:"title"`                                              // first line without the [task:*] prefix
+	Flag      string         `yaml:"flag,omitempty" toml:"flag,omitempty" json:"flag,omitempty"`                   // status, todo when creating if empty
+	Spec      string         `yaml:"spec,omitempty" toml:"spec,multiline,omitempty" json:"spec,omitempty"`         // description body
+	Parents   []string       `yaml:"parents,omitempty" toml:"parents,omitempty" json:"parents,omitempty"`          // plan keys or revisions
+	DependsOn []string       `yaml:"depends_on,omitempty" toml:"depends_on,omitempty" json:"depends_on,omitempty"` // plan keys or revisions
+	Trailers  map[string]any `yaml:"trailers,omitempty" toml:"trailers,omitempty" json:"trailers,omitempty"`       // value or list of values per key
+}
+
+// FormatFromPath picks the plan format from a file extension
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	case ".json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown plan format for %s (use .yaml, .toml or .json)", path)
+}
+
+// Load reads a plan file; "-" reads YAML from stdin
+func Load(path string) (*Plan, error) {
+	var data []byte
+	var err error
+	format := FormatYAML
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		if format, err = FormatFromPath(path); err != nil {
+			return nil, err
+		}
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, format)
+}
+
+// Parse decodes and validates a plan
+func Parse(data []byte, format string) (*Plan, error) {
+	p := &Plan{}
+	var err error
+	switch format {
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(p); err == io.EOF {
+			err = nil
+		}
+	case FormatTOML:
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(p)
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(p)
+	default:
+		return nil, fmt.Errorf("unknown plan format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s plan: %w", format, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Marshal encodes p in format
+func Marshal(p *Plan, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(p); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), enc.Close()
+	case FormatTOML:
+		return toml.Marshal(p)
+	case FormatJSON:
+		data, err := json.MarshalIndent(p, "", "  ")
+		return append(data, '\n'), err
+	}
+	return nil, fmt.Errorf("unknown plan format %q", format)
+}
+
+// Get returns the task with key, or nil
+func (p *Plan) Get(key string) *Task {
+	for i := range p.Tasks {
+		if p.Tasks[i].Key == key {
+			return &p.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// TrailerList flattens Trailers sorted by key, with one trailer per list
+// element. Keys are returned as written.
+func (t Task) TrailerList() (task.Trailers, error) {
+	var trailers task.Trailers
+	for _, key := range slices.Sorted(maps.Keys(t.Trailers)) {
+		var values []any
+		switch v := t.Trailers[key].(type) {
+		case []any:
+			values = v
+		case []string:
+			for _, s := range v {
+				values = append(values, s)
+			}
+		default:
+			values = []any{v}
+		}
+		for _, v := range values {
+			value, err := scalar(v)
+			if err != nil {
+				return nil, fmt.Errorf("trailer %s: %w", key, err)
+			}
+			trailers = append(trailers, task.Trailer{Key: key, Value: value})
+		}
+	}
+	return trailers, nil
+}
+
+// scalar renders a decoded trailer value. YAML and TOML decode bare dates
+// and numbers, so those are formatted back to their written form.
+func scalar(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format(task.DueLayout), nil
+		}
+		return v.Format(time.RFC3339), nil
+	case bool, int, int64, uint64, float64, fmt.Stringer:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// SetTrailers replaces Trailers with trailers, using a list for repeated keys
+func (t *Task) SetTrailers(trailers task.Trailers) {
+	t.Trailers = nil
+	for _, tr := range trailers {
+		if t.Trailers == nil {
+			t.Trailers = make(map[string]any)
+		}
+		switch existing := t.Trailers[tr.Key].(type) {
+		case nil:
+			t.Trailers[tr.Key] = tr.Value
+		case string:
+			t.Trailers[tr.Key] = []string{existing, tr.Value}
+		case []string:
+			t.Trailers[tr.Key] = append(existing, tr.Value)
+		}
+	}
+}
+
+// Validate checks keys and titles, and that parent and depends_on edges
+// between plan tasks form a DAG
+func (p *Plan) Validate() error {
+	seen := make(map[string]bool, len(p.Tasks))
+	for i, t := range p.Tasks {
+		switch {
+		case t.Key == "":
+			return fmt.Errorf("task %d: missing key", i+1)
+		case strings.ContainsAny(t.Key, " \t\n"):
+			return fmt.Errorf("task %s: key must not contain whitespace", t.Key)
+		case seen[t.Key]:
+			return fmt.Errorf("task %s: duplicate key", t.Key)
+		case strings.TrimSpace(t.Title) == "" || strings.Contains(t.Title, "\n"):
+			return fmt.Errorf("task %s: title must be a single non-empty line", t.Key)
+		}
+		if _, err := t.TrailerList(); err != nil {
+			return fmt.Errorf("task %s: %w", t.Key, err)
+		}
+		seen[t.Key] = true
+	}
+	_, err := p.Order()
+	return err
+}
+
+// Order returns the tasks with every plan parent and dependency before the
+// tasks that name it, otherwise keeping file order
+func (p *Plan) Order() ([]Task, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(p.Tasks))
+	ordered := make([]Task, 0, len(p.Tasks))
+
+	var visit func(t *Task, path []string) error
+	visit = func(t *Task, path []string) error {
+		switch state[t.Key] {
+		case visited:
+			return nil
+		case visiting:
+			i := slices.Index(path, t.Key)
+			return fmt.Errorf("cycle: %s", strings.Join(append(path[i:], t.Key), " → "))
+		}
+		state[t.Key] = visiting
+		for _, ref := range slices.Concat(t.Parents, t.DependsOn) {
+			if dep := p.Get(ref); dep != nil {
+				if err := visit(dep, append(path, t.Key)); err != nil {
+					return err
+				}
+			}
+		}
+		state[t.Key] = visited
+		ordered = append(ordered, *t)
+		return nil
+	}
+
+	for i := range p.Tasks {
+		if err := visit(&p.Tasks[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,116 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+)
+
+func keys(tasks []Task) string {
+	var ks []string
+	for _, t := range tasks {
+		ks = append(ks, t.Key)
+	}
+	return strings.Join(ks, ",")
+}
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{FormatYAML, `
+tasks:
+  - key: api
+    title: Endpoints
+    parents: [schema]
+  - key: schema
+    title: Schema
+    spec: |
+      - [ ] migration
+    trailers:
+      Priority: high
+`},
+		{FormatTOML, `
+[[tasks]]
+key = "api"
+title = "Endpoints"
+parents = ["schema"]
+
+[[tasks]]
+key = "schema"
+title = "Schema"
+spec = """
+- [ ] migration
+"""
+trailers = { Priority = "high" }
+`},
+		{FormatJSON, `{"tasks": [
+  {"key": "api", "title": "Endpoints", "parents": ["schema"]},
+  {"key": "schema", "title": "Schema", "spec": "- [ ] migration\n", "trailers": {"Priority": "high"}}
+]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			p, err := Parse([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			schema := p.Get("schema")
+			if schema == nil || schema.Spec != "- [ ] migration\n" || schema.Trailers["Priority"] != "high" {
+				t.Errorf("schema = %+v", schema)
+			}
+			ordered, err := p.Order()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := keys(ordered); got != "schema,api" {
+				t.Errorf("Order = %s, want schema,api", got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"missing key", "tasks: [{title: A}]", "missing key"},
+		{"duplicate key", "tasks: [{key: a, title: A}, {key: a, title: B}]", "duplicate key"},
+		{"empty title", "tasks: [{key: a}]", "title"},
+		{"unknown field", "tasks: [{key: a, title: A, parent: b}]", "parent"},
+		{"cycle", "tasks: [{key: a, title: A, parents: [b]}, {key: b, title: B, parents: [a]}]", "a → b → a"},
+		{"dependency cycle", "tasks: [{key: a, title: A, depends_on: [b]}, {key: b, title: B, parents: [a]}]", "a → b → a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), FormatYAML)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderFollowsDependencies(t *testing.T) {
+	p, err := Parse([]byte(`
+tasks:
+  - key: api
+    title: Endpoints
+    depends_on: [auth]
+  - key: auth
+    title: Auth
+`), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordered, err := p.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keys(ordered); got != "auth,api" {
+		t.Errorf("Order = %s, want auth,api", got)
+	}
+}
@@ -0,0 +1,124 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CheckItem is a Markdown checkbox ("- [ ] text") in a task description
+type CheckItem struct {
+	Line int    // line index within the description
+	Text string // text after the checkbox
+	Done bool   // checked ("- [x]")
+}
+
+var checkboxPattern = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])(\] ?)(.*)$`)
+
+// fencePattern matches a Markdown code fence line, ``` or ~~~
+var fencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// ParseChecklist returns every checkbox in desc in order, skipping lines
+// inside fenced code blocks
+func ParseChecklist(desc string) []CheckItem {
+	var items []CheckItem
+	fence := ""
+	for i, line := range strings.Split(desc, "\n") {
+		if f := fencePattern.FindStringSubmatch(line); f != nil {
+			switch {
+			case fence == "":
+				fence = f[1]
+			case f[1][0] == fence[0] && len(f[1]) >= len(fence) && strings.TrimSpace(line[len(f[0]):]) == "":
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+		m := checkboxPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		items = append(items, CheckItem{
+			Line: i,
+			Text: strings.TrimSpace(m[4]),
+			Done: m[2] != " ",
+		})
+	}
+	return items
+}
+
+// Progress counts checked and total checkbox items
+func Progress(items []CheckItem) (done, total int) {
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(items)
+}
+
+// Unchecked returns the items that are not checked yet
+func Unchecked(items []CheckItem) []CheckItem {
+	var open []CheckItem
+	for _, item := range items {
+		if !item.Done {
+			open = append(open, item)
+		}
+	}
+	return open
+}
+
+// FindCheckItem selects an item by 1-based number or by case-insensitive
+// text match. Text must match exactly one item, unless it equals one item's
+// text exactly.
+func FindCheckItem(items []CheckItem, query string) (CheckItem, error) {
+	if len(items) == 0 {
+		return CheckItem{}, fmt.Errorf("task has no checklist items")
+	}
+	if n, err := strconv.Atoi(query); err == nil {
+		if n < 1 || n > len(items) {
+			return CheckItem{}, fmt.Errorf("item %d out of range (1-%d)", n, len(items))
+		}
+		return items[n-1], nil
+	}
+
+	needle := strings.ToLower(query)
+	var matches []CheckItem
+	for _, item := range items {
+		text := strings.ToLower(item.Text)
+		if text == needle {
+			return item, nil
+		}
+		if strings.Contains(text, needle) {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return CheckItem{}, fmt.Errorf("no checklist item matches %q", query)
+	case 1:
+		return matches[0], nil
+	}
+	var lines []string
+	for _, m := range matches {
+		lines = append(lines, "  "+m.Text)
+	}
+	return CheckItem{}, fmt.Errorf("%q matches %d items:\n%s", query, len(matches), strings.Join(lines, "\n"))
+}
+
+// SetChecked ticks or unticks the checkbox on item's line
+func SetChecked(desc string, item CheckItem, done bool) string {
+	lines := strings.Split(desc, "\n")
+	if item.Line >= len(lines) {
+		return desc
+	}
+	mark := " "
+	if done {
+		mark = "x"
+	}
+	lines[item.Line] = checkboxPattern.ReplaceAllString(lines[item.Line], "${1}"+mark+"${3}${4}")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,81 @@
+package task
+
+import (
+	"strings"
+	"testing"
+)
+
+const checklistDesc = `[task:wip] Add cache
+
+## Acceptance criteria
+- [x] Cache hits skip the database
+- [ ] Entries expire after TTL
+  * [ ] Expiry is configurable
+- [] not a checkbox
+`
+
+func TestParseChecklist(t *testing.T) {
+	items := ParseChecklist(checklistDesc)
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3: %+v", len(items), items)
+	}
+	if !items[0].Done || items[1].Done || items[2].Text != "Expiry is configurable" {
+		t.Errorf("items = %+v", items)
+	}
+	if done, total := Progress(items); done != 1 || total != 3 {
+		t.Errorf("Progress = %d/%d, want 1/3", done, total)
+	}
+}
+
+func TestParseChecklistSkipsCodeFences(t *testing.T) {
+	desc := "[task:todo] Document checklists\n\n" +
+		"- [ ] Explain the syntax\n" +
+		"```markdown\n" +
+		"- [ ] example item\n" +
+		"~~~\n" +
+		"- [x] still inside\n" +
+		"```\n" +
+		"~~~~\n" +
+		"* [ ] tilde fenced\n" +
+		"~~~~\n" +
+		"- [x] Add an example\n"
+
+	items := ParseChecklist(desc)
+	if len(items) != 2 || items[0].Text != "Explain the syntax" || items[1].Text != "Add an example" {
+		t.Fatalf("items = %+v, want only the two outside fences", items)
+	}
+	if items[1].Line != 11 {
+		t.Errorf("Line = %d, want 11", items[1].Line)
+	}
+}
+
+func TestFindCheckItem(t *testing.T) {
+	items := ParseChecklist(checklistDesc)
+
+	if item, err := FindCheckItem(items, "2"); err != nil || item.Text != "Entries expire after TTL" {
+		t.Errorf("FindCheckItem(2) = %+v, %v", item, err)
+	}
+	if item, err := FindCheckItem(items, "configurable"); err != nil || item.Line != 5 {
+		t.Errorf("FindCheckItem(configurable) = %+v, %v", item, err)
+	}
+	if _, err := FindCheckItem(items, "e"); err == nil {
+		t.Error("ambiguous text should fail")
+	}
+	if _, err := FindCheckItem(items, "4"); err == nil {
+		t.Error("out of range number should fail")
+	}
+}
+
+func TestSetChecked(t *testing.T) {
+	items := ParseChecklist(checklistDesc)
+	desc := SetChecked(checklistDesc, items[2], true)
+	desc = SetChecked(desc, items[0], false)
+
+	got := ParseChecklist(desc)
+	if got[0].Done || !got[2].Done || got[2].Text != "Expiry is configurable" {
+		t.Errorf("after SetChecked: %+v", got)
+	}
+	if want := "\n  * [x] Expiry is configurable\n"; !strings.Contains(desc, want) {
+		t.Errorf("indentation not preserved:\n%s", desc)
+	}
+}
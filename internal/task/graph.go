@@ -0,0 +1,628 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"jjtask/internal/jj"
+)
+
+// graphRevset covers every task, @ and its parents
+const graphRevset = "tasks() | parents(@) | @"
+
+// linkDepth bounds how many generations below a loaded revision the graph
+// looks for revisions linking it to loaded ancestors. Older history, such as
+// the trunk between long-done tasks, is not loaded, so ancestry through it
+// is not seen.
+const linkDepth = 100
+
+// Graph is an in-memory view of the task DAG, loaded once per invocation.
+// It reloads itself when the client has run mutating commands since loading.
+type Graph struct {
+	client     *jj.Client
+	extra      []string // additional revsets pulled in by Resolve
+	generation int
+	crossRepo  func(ref string) *Task
+	outside    map[string]*Task // Depends-On references queried outside the loaded revset
+
+	nodes []*Task // jj log order (children before parents)
+	byID  map[string]*Task
+	links map[string]*Task // revisions between nodes, walked but not exposed
+}
+
+// LoadGraph loads the task graph with one jj call
+func LoadGraph(c *jj.Client) (*Graph, error) {
+	g := &Graph{client: c}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewGraph builds a graph from already loaded revisions (no reloading)
+func NewGraph(nodes []*Task) *Graph {
+	g := &Graph{}
+	g.index(nodes, nil)
+	return g
+}
+
+func (g *Graph) load() error {
+	revset := graphRevset
+	if len(g.extra) > 0 {
+		revset += " | " + strings.Join(g.extra, " | ")
+	}
+	nodes, err := Load(g.client, revset)
+	if err != nil {
+		return err
+	}
+	var links []*Task
+	if hasOutsideParents(nodes) {
+		// Revisions on paths between loaded ones, at most linkDepth
+		// generations up from the lower end
+		links, err = Load(g.client, fmt.Sprintf("(ancestors(%[1]s, %[2]d) & descendants(%[1]s)) ~ (%[1]s)", revset, linkDepth))
+		if err != nil {
+			return err
+		}
+	}
+	g.index(nodes, links)
+	g.outside = nil
+	g.generation = g.client.Generation()
+	return nil
+}
+
+// hasOutsideParents reports whether a revision has a parent outside nodes,
+// which may link it to another of nodes
+func hasOutsideParents(nodes []*Task) bool {
+	ids := make(map[string]bool, len(nodes))
+	for _, t := range nodes {
+		ids[t.ChangeID] = true
+	}
+	return slices.ContainsFunc(nodes, func(t *Task) bool {
+		return slices.ContainsFunc(t.Parents, func(p string) bool { return !ids[p] })
+	})
+}
+
+func (g *Graph) index(nodes, links []*Task) {
+	g.nodes = nodes
+	g.byID = make(map[string]*Task, len(nodes))
+	for _, t := range nodes {
+		g.byID[t.ChangeID] = t
+	}
+	g.links = make(map[string]*Task, len(links))
+	for _, t := range links {
+		if g.byID[t.ChangeID] == nil {
+			g.links[t.ChangeID] = t
+		}
+	}
+	if len(g.links) == 0 {
+		return
+	}
+	// Children were only known within each jj call
+	all := slices.Concat(nodes, slices.Collect(maps.Values(g.links)))
+	for _, t := range all {
+		t.Children = nil
+	}
+	for _, t := range all {
+		for _, p := range t.Parents {
+			if parent := g.node(p); parent != nil {
+				parent.Children = append(parent.Children, t.ChangeID)
+			}
+		}
+	}
+}
+
+// node returns the loaded revision or link with the given shortest change ID
+func (g *Graph) node(id string) *Task {
+	if t, ok := g.byID[id]; ok {
+		return t
+	}
+	return g.links[id]
+}
+
+// SetCrossRepo sets how Depends-On references naming another repo, such as
+// "api:xqq", are resolved. lookup returns nil for references it does not
+// handle; tasks it returns must have Repo set.
+func (g *Graph) SetCrossRepo(lookup func(ref string) *Task) {
+	g.crossRepo = lookup
+}
+
+// SetClient makes the graph query through c, a copy of its client with other
+// output streams, from the generation c is at. Refresh the graph first.
+func (g *Graph) SetClient(c *jj.Client) {
+	g.client = c
+	g.generation = c.Generation()
+}
+
+// Refresh reloads the graph if the repo was mutated since it was loaded
+func (g *Graph) Refresh() error {
+	if g.client == nil || g.client.Generation() == g.generation {
+		return nil
+	}
+	return g.load()
+}
+
+// Get returns the revision with the given shortest change ID, or nil
+func (g *Graph) Get(id string) *Task {
+	return g.byID[id]
+}
+
+// Resolve maps a user-supplied revision to a graph node. Exact change IDs
+// and @ are answered in memory; other revsets, including change ID prefixes
+// that could also name a bookmark or tag, fall back to one jj query and pull
+// the revision into the graph if it was outside the loaded window. Callers
+// should pass the returned task's ID to jj rather than rev.
+func (g *Graph) Resolve(rev string) (*Task, error) {
+	if err := g.Refresh(); err != nil {
+		return nil, err
+	}
+	if rev == "@" {
+		if at := g.WorkingCopy(); at != nil {
+			return at, nil
+		}
+	}
+	if t, ok := g.byID[rev]; ok {
+		return t, nil
+	}
+	if i := slices.IndexFunc(g.nodes, func(t *Task) bool { return t.ID == rev }); i >= 0 {
+		return g.nodes[i], nil
+	}
+
+	if g.client == nil {
+		// Without jj there is nothing else the prefix could name
+		if t := g.byPrefix(rev); t != nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("revision %q not found", rev)
+	}
+	t, err := Get(g.client, rev)
+	if err != nil {
+		return nil, err
+	}
+	if node, ok := g.byID[t.ChangeID]; ok {
+		return node, nil
+	}
+
+	g.extra = append(g.extra, t.ChangeID)
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	if node, ok := g.byID[t.ChangeID]; ok {
+		return node, nil
+	}
+	return t, nil
+}
+
+// byPrefix finds the single node whose full change ID starts with prefix
+func (g *Graph) byPrefix(prefix string) *Task {
+	if prefix == "" {
+		return nil
+	}
+	var found *Task
+	for _, t := range g.nodes {
+		if strings.HasPrefix(t.ID, prefix) {
+			if found != nil {
+				return nil // ambiguous, let jj report it
+			}
+			found = t
+		}
+	}
+	return found
+}
+
+// Nodes returns every loaded revision in jj log order
+func (g *Graph) Nodes() []*Task {
+	return g.nodes
+}
+
+// Tasks returns task revisions in jj log order
+func (g *Graph) Tasks() []*Task {
+	return g.filter(func(t *Task) bool { return t.IsTask() })
+}
+
+// WithFlag returns tasks with the given flag in jj log order
+func (g *Graph) WithFlag(flag string) []*Task {
+	return g.filter(func(t *Task) bool { return t.Flag == flag })
+}
+
+// Pending returns tasks that are not done in jj log order
+func (g *Graph) Pending() []*Task {
+	return g.filter(IsPending)
+}
+
+// WorkingCopy returns the @ revision
+func (g *Graph) WorkingCopy() *Task {
+	for _, t := range g.nodes {
+		if t.WorkingCopy {
+			return t
+		}
+	}
+	return nil
+}
+
+// Parents returns the loaded parents of id
+func (g *Graph) Parents(id string) []*Task {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	var parents []*Task
+	for _, p := range t.Parents {
+		if node, ok := g.byID[p]; ok {
+			parents = append(parents, node)
+		}
+	}
+	return parents
+}
+
+// Children returns the loaded children of id
+func (g *Graph) Children(id string) []*Task {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	var children []*Task
+	for _, c := range t.Children {
+		if node, ok := g.byID[c]; ok {
+			children = append(children, node)
+		}
+	}
+	return children
+}
+
+// Ancestors returns the proper ancestors of id in jj log order
+func (g *Graph) Ancestors(id string) []*Task {
+	seen := g.walk(id, func(t *Task) []string { return t.Parents })
+	return g.filter(func(t *Task) bool { return seen[t.ChangeID] })
+}
+
+// Descendants returns the proper descendants of id in jj log order
+func (g *Graph) Descendants(id string) []*Task {
+	seen := g.walk(id, func(t *Task) []string { return t.Children })
+	return g.filter(func(t *Task) bool { return seen[t.ChangeID] })
+}
+
+// ClosestAncestors returns the nearest proper ancestors of id matching keep,
+// without looking past a match, in jj log order. Links between loaded
+// revisions are passed through without calling keep.
+func (g *Graph) ClosestAncestors(id string, keep func(*Task) bool) []*Task {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	found := make(map[string]bool)
+	seen := make(map[string]bool)
+	queue := slices.Clone(t.Parents)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		node := g.node(cur)
+		if seen[cur] || node == nil {
+			continue
+		}
+		seen[cur] = true
+		if _, loaded := g.byID[cur]; loaded && keep(node) {
+			found[cur] = true
+		} else {
+			queue = append(queue, node.Parents...)
+		}
+	}
+	return g.filter(func(t *Task) bool { return found[t.ChangeID] })
+}
+
+// IsAncestorOf reports whether rev is target or one of its ancestors (rev::target)
+func (g *Graph) IsAncestorOf(rev, target string) bool {
+	if rev == target {
+		return true
+	}
+	return g.walk(target, func(t *Task) []string { return t.Parents })[rev]
+}
+
+// Heads returns the ids that are not ancestors of another id in the list,
+// in jj log order
+func (g *Graph) Heads(ids []string) []*Task {
+	var heads []*Task
+	for _, t := range g.nodes {
+		if !slices.Contains(ids, t.ChangeID) {
+			continue
+		}
+		isHead := true
+		for _, other := range ids {
+			if other != t.ChangeID && g.IsAncestorOf(t.ChangeID, other) {
+				isHead = false
+				break
+			}
+		}
+		if isHead {
+			heads = append(heads, t)
+		}
+	}
+	return heads
+}
+
+// DeepestPendingDescendant returns the leaf of the pending task chain
+// starting at id (id itself included), or "" if there are no pending tasks
+func (g *Graph) DeepestPendingDescendant(id string) string {
+	var candidates []*Task
+	descendants := g.walk(id, func(t *Task) []string { return t.Children })
+	for _, t := range g.nodes {
+		if (t.ChangeID == id || descendants[t.ChangeID]) && IsPending(t) {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	// The leaf is a candidate without pending children
+	for _, c := range candidates {
+		hasPending := false
+		for _, child := range g.Children(c.ChangeID) {
+			if IsPending(child) {
+				hasPending = true
+				break
+			}
+		}
+		if !hasPending {
+			return c.ChangeID
+		}
+	}
+	return candidates[len(candidates)-1].ChangeID
+}
+
+// Ready returns todo tasks whose direct task parents are todo or done,
+// matching the tasks_ready() revset, and whose Depends-On tasks are done
+func (g *Graph) Ready() []*Task {
+	return g.filter(g.isReady)
+}
+
+// IsReady reports whether the task with id is in Ready()
+func (g *Graph) IsReady(id string) bool {
+	t := g.byID[id]
+	return t != nil && g.isReady(t)
+}
+
+func (g *Graph) isReady(t *Task) bool {
+	if t.Flag != "todo" {
+		return false
+	}
+	for _, p := range g.Parents(t.ChangeID) {
+		if IsPending(p) && p.Flag != "todo" {
+			return false
+		}
+	}
+	return len(g.UnmetDependencies(t.ChangeID)) == 0
+}
+
+// NextReady returns Ready() tasks in the order they should be picked up:
+// tasks that start on @'s ancestry (tasks_next()) first, then tasks with
+// fewer pending ancestors, then the oldest
+func (g *Graph) NextReady() []*Task {
+	ready := g.Ready()
+	onAncestry := make(map[string]bool, len(ready))
+	depth := make(map[string]int, len(ready))
+	at := g.WorkingCopy()
+	for _, t := range ready {
+		for _, p := range g.Parents(t.ChangeID) {
+			if at != nil && g.IsAncestorOf(p.ChangeID, at.ChangeID) {
+				onAncestry[t.ChangeID] = true
+			}
+		}
+		for _, a := range g.Ancestors(t.ChangeID) {
+			if IsPending(a) {
+				depth[t.ChangeID]++
+			}
+		}
+	}
+
+	slices.SortStableFunc(ready, func(a, b *Task) int {
+		if onAncestry[a.ChangeID] != onAncestry[b.ChangeID] {
+			if onAncestry[a.ChangeID] {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(depth[a.ChangeID], depth[b.ChangeID]); c != 0 {
+			return c
+		}
+		return a.Created.Compare(b.Created)
+	})
+	return ready
+}
+
+// Lookup finds a node by shortest change ID or unique full change ID prefix,
+// as written in Depends-On trailers
+func (g *Graph) Lookup(ref string) *Task {
+	if t, ok := g.byID[ref]; ok {
+		return t
+	}
+	return g.byPrefix(ref)
+}
+
+// LookupDependency resolves a Depends-On reference, trying other repos and
+// then the rest of the repo when it is not in the loaded graph
+func (g *Graph) LookupDependency(ref string) *Task {
+	if t := g.Lookup(ref); t != nil {
+		return t
+	}
+	if g.crossRepo != nil {
+		if t := g.crossRepo(ref); t != nil {
+			return t
+		}
+	}
+	return g.lookupOutside(ref)
+}
+
+// lookupOutside queries jj once per reference for a task outside the loaded
+// revset, such as a done task that fell out of tasks()
+func (g *Graph) lookupOutside(ref string) *Task {
+	if g.client == nil {
+		return nil
+	}
+	if t, ok := g.outside[ref]; ok {
+		return t
+	}
+	t, err := Get(g.client, ref)
+	if err != nil || !t.IsTask() {
+		t = nil
+	}
+	if g.outside == nil {
+		g.outside = make(map[string]*Task)
+	}
+	g.outside[ref] = t
+	return t
+}
+
+// Dependencies returns the tasks that id declares with Depends-On, including
+// tasks from other repos when SetCrossRepo is used. References that cannot
+// be resolved are skipped; see MissingDependencies.
+func (g *Graph) Dependencies(id string) []*Task {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	var deps []*Task
+	for _, ref := range t.DependsOn {
+		if dep := g.LookupDependency(ref); dep != nil && !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// Dependents returns tasks that declare Depends-On id, in jj log order
+func (g *Graph) Dependents(id string) []*Task {
+	return g.filter(func(t *Task) bool {
+		return slices.ContainsFunc(g.Dependencies(t.ChangeID), func(d *Task) bool { return d.Repo == "" && d.ChangeID == id })
+	})
+}
+
+// MissingDependencies returns the Depends-On references of id that do not
+// resolve to a task, e.g. because it was abandoned or the ID is mistyped
+func (g *Graph) MissingDependencies(id string) []string {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	var missing []string
+	for _, ref := range t.DependsOn {
+		if g.LookupDependency(ref) == nil && !slices.Contains(missing, ref) {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// UnmetDependencies returns the Depends-On tasks of id that are not done.
+// References that do not resolve count as unmet, as placeholder tasks with
+// the reference as ChangeID and no flag.
+func (g *Graph) UnmetDependencies(id string) []*Task {
+	var unmet []*Task
+	for _, dep := range g.Dependencies(id) {
+		if !IsDone(dep.Flag) {
+			unmet = append(unmet, dep)
+		}
+	}
+	for _, ref := range g.MissingDependencies(id) {
+		unmet = append(unmet, &Task{ChangeID: ref, Title: "(not found)", Description: "(not found)"})
+	}
+	return unmet
+}
+
+// DependencyCycle returns the cycle that declaring "from Depends-On to" would
+// create, as change IDs starting and ending with from, or nil if there is
+// none. A task waits for its parents and its declared dependencies, so
+// depending on a descendant is a cycle too.
+func (g *Graph) DependencyCycle(from, to string) []string {
+	if from == to {
+		return []string{from, from}
+	}
+	prev := map[string]string{to: ""}
+	queue := []string{to}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == from {
+			path := []string{from}
+			for id := prev[cur]; id != ""; id = prev[id] {
+				path = append(path, id)
+			}
+			// path runs from back to to; reverse into from -> to -> ... -> from
+			slices.Reverse(path)
+			return append([]string{from}, path...)
+		}
+		for _, next := range g.waitsFor(cur) {
+			if _, seen := prev[next]; !seen {
+				prev[next] = cur
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// waitsFor returns the IDs a node cannot finish before: parents and
+// dependencies in the same repo
+func (g *Graph) waitsFor(id string) []string {
+	t := g.byID[id]
+	if t == nil {
+		return nil
+	}
+	ids := slices.Clone(t.Parents)
+	for _, dep := range g.Dependencies(id) {
+		if dep.Repo == "" {
+			ids = append(ids, dep.ChangeID)
+		}
+	}
+	return ids
+}
+
+// DoneFlags lists the flags that count as complete. jjtask adds custom
+// statuses configured as done in .jjtask.toml.
+var DoneFlags = []string{"done"}
+
+// IsDone reports whether flag counts as complete
+func IsDone(flag string) bool {
+	return slices.Contains(DoneFlags, flag)
+}
+
+// IsPending reports whether t is a task that is not done
+func IsPending(t *Task) bool {
+	return t.IsTask() && !IsDone(t.Flag)
+}
+
+// walk collects all nodes reachable from id via next, excluding id
+func (g *Graph) walk(id string, next func(*Task) []string) map[string]bool {
+	seen := make(map[string]bool)
+	start := g.byID[id]
+	if start == nil {
+		return seen
+	}
+	queue := next(start)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if seen[cur] {
+			continue
+		}
+		node := g.node(cur)
+		if node == nil {
+			continue
+		}
+		seen[cur] = true
+		queue = append(queue, next(node)...)
+	}
+	return seen
+}
+
+func (g *Graph) filter(keep func(*Task) bool) []*Task {
+	var result []*Task
+	for _, t := range g.nodes {
+		if keep(t) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
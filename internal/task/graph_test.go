@@ -0,0 +1,303 @@
+package task
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"jjtask/internal/jj/jjtest"
+)
+
+// buildGraph creates a graph from "id parents... : description" specs in jj log order
+func buildGraph(t *testing.T, specs ...string) *Graph {
+	t.Helper()
+	nodes, err := Parse(logOutput(t, specs...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGraph(nodes)
+}
+
+// logOutput renders buildGraph specs as jj log output in logTemplate's format
+func logOutput(t *testing.T, specs ...string) string {
+	t.Helper()
+	var lines []string
+	for _, spec := range specs {
+		ids, desc, _ := strings.Cut(spec, ":")
+		fields := strings.Fields(ids)
+		r := record{
+			ID:          fields[0] + "full",
+			ChangeID:    fields[0],
+			Parents:     fields[1:],
+			Empty:       true,
+			WorkingCopy: fields[0] == "at",
+			Description: strings.TrimSpace(desc),
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(data))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func ids(tasks []*Task) string {
+	return strings.Join(IDs(tasks), ",")
+}
+
+func TestGraphAncestry(t *testing.T) {
+	// base <- a <- b <- at, base <- c
+	g := buildGraph(t,
+		"at b c: merge",
+		"c base: [task:blocked] C",
+		"b a: [task:todo] B",
+		"a base: [task:done] A",
+		"base: Base",
+	)
+
+	if got := ids(g.Ancestors("b")); got != "a,base" {
+		t.Errorf("Ancestors(b) = %s", got)
+	}
+	if got := ids(g.Descendants("a")); got != "at,b" {
+		t.Errorf("Descendants(a) = %s", got)
+	}
+	if !g.IsAncestorOf("a", "at") || g.IsAncestorOf("c", "b") || !g.IsAncestorOf("b", "b") {
+		t.Error("IsAncestorOf gave wrong answer")
+	}
+	if got := ids(g.Heads([]string{"a", "b", "c"})); got != "c,b" {
+		t.Errorf("Heads = %s", got)
+	}
+	if got := g.WorkingCopy(); got == nil || got.ChangeID != "at" {
+		t.Errorf("WorkingCopy = %v", got)
+	}
+	if got := ids(g.Pending()); got != "c,b" {
+		t.Errorf("Pending = %s", got)
+	}
+}
+
+func TestLoadGraphLinksThroughUnloadedRevisions(t *testing.T) {
+	// base <- a <- m <- at, where m is neither a task nor @'s parent
+	fake := jjtest.New()
+	fake.On("log", "-r", graphRevset).Returns(logOutput(t,
+		"at m: work",
+		"a base: [task:done] A",
+		"base: Base",
+	))
+	fake.On("log").Returns(logOutput(t, "m a: plain"))
+	g, err := LoadGraph(fake.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !g.IsAncestorOf("a", "at") {
+		t.Error("a should be an ancestor of at through m")
+	}
+	if got := ids(g.Nodes()); got != "at,a,base" {
+		t.Errorf("Nodes = %s, want links left out", got)
+	}
+	if got := ids(g.Descendants("a")); got != "at" {
+		t.Errorf("Descendants(a) = %s", got)
+	}
+	if got := ids(g.ClosestAncestors("at", (*Task).IsTask)); got != "a" {
+		t.Errorf("ClosestAncestors(at) = %s", got)
+	}
+	if calls := fake.Commands("log"); len(calls) != 2 || strings.Contains(calls[0], "connected") {
+		t.Errorf("log calls = %q, want the loaded revset and one link query", calls)
+	}
+}
+
+func TestGraphDeepestPendingDescendant(t *testing.T) {
+	g := buildGraph(t,
+		"d c: [task:todo] D",
+		"c b: [task:todo] C",
+		"b at: [task:todo] B",
+		"at base: work",
+		"base: Base",
+	)
+
+	if got := g.DeepestPendingDescendant("at"); got != "d" {
+		t.Errorf("DeepestPendingDescendant(at) = %q, want d", got)
+	}
+	if got := g.DeepestPendingDescendant("d"); got != "d" {
+		t.Errorf("DeepestPendingDescendant(d) = %q, want d", got)
+	}
+	if got := g.DeepestPendingDescendant("base"); got != "d" {
+		t.Errorf("DeepestPendingDescendant(base) = %q, want d", got)
+	}
+}
+
+func TestGraphReady(t *testing.T) {
+	g := buildGraph(t,
+		"e d: [task:todo] E",
+		"d base: [task:wip] D",
+		"c b: [task:todo] C",
+		"b base: [task:todo] B",
+		"a base: [task:draft] A",
+		"base: Base",
+	)
+
+	if got := ids(g.Ready()); got != "c,b" {
+		t.Errorf("Ready = %s, want c,b", got)
+	}
+}
+
+func TestGraphNextReady(t *testing.T) {
+	g := buildGraph(t,
+		"z x: [task:todo] Z",
+		"y side: [task:todo] Y",
+		"side base: Side",
+		"x base: [task:todo] X",
+		"at base: work",
+		"base: Base",
+	)
+
+	// x starts on @'s ancestry; y is off it; z is stacked on pending x
+	if got := ids(g.NextReady()); got != "x,y,z" {
+		t.Errorf("NextReady = %s, want x,y,z", got)
+	}
+}
+
+func TestGraphClosestAncestors(t *testing.T) {
+	g := buildGraph(t,
+		"c m: [task:todo] C",
+		"m a b: merge",
+		"b base: [task:todo] B",
+		"a x: [task:todo] A",
+		"x base: work",
+		"base: Base",
+	)
+
+	got := ids(g.ClosestAncestors("c", (*Task).IsTask))
+	if got != "b,a" {
+		t.Errorf("ClosestAncestors(c) = %s, want b,a", got)
+	}
+}
+
+func TestGraphResolvePrefix(t *testing.T) {
+	g := buildGraph(t,
+		"kx: [task:todo] X",
+		"ky: [task:todo] Y",
+		"at: work",
+	)
+
+	got, err := g.Resolve("kxf")
+	if err != nil || got.ChangeID != "kx" {
+		t.Errorf("Resolve(kxf) = %v, %v", got, err)
+	}
+	if at, err := g.Resolve("@"); err != nil || at.ChangeID != "at" {
+		t.Errorf("Resolve(@) = %v, %v", at, err)
+	}
+	if _, err := g.Resolve("k"); err == nil {
+		t.Error("ambiguous prefix should not resolve without jj")
+	}
+}
+
+func TestGraphDependencies(t *testing.T) {
+	g := buildGraph(t,
+		"c base: [task:todo] C\n\nDepends-On: afull\nDepends-On: b",
+		"b base: [task:wip] B",
+		"a base: [task:done] A",
+		"base: Base",
+	)
+
+	if got := ids(g.Dependencies("c")); got != "a,b" {
+		t.Errorf("Dependencies(c) = %s, want a,b", got)
+	}
+	if got := ids(g.UnmetDependencies("c")); got != "b" {
+		t.Errorf("UnmetDependencies(c) = %s, want b", got)
+	}
+	if got := ids(g.Dependents("b")); got != "c" {
+		t.Errorf("Dependents(b) = %s, want c", got)
+	}
+	if g.IsReady("c") {
+		t.Error("c should not be ready while b is pending")
+	}
+}
+
+func TestGraphDanglingDependency(t *testing.T) {
+	g := buildGraph(t,
+		"c base: [task:todo] C\n\nDepends-On: gone\nDepends-On: a",
+		"a base: [task:done] A",
+		"base: Base",
+	)
+
+	if got := g.MissingDependencies("c"); len(got) != 1 || got[0] != "gone" {
+		t.Errorf("MissingDependencies(c) = %v, want gone", got)
+	}
+	if got := ids(g.UnmetDependencies("c")); got != "gone" {
+		t.Errorf("UnmetDependencies(c) = %s, want gone", got)
+	}
+	if g.IsReady("c") {
+		t.Error("c should not be ready while a dependency is missing")
+	}
+}
+
+func TestGraphCustomDoneFlag(t *testing.T) {
+	prev := DoneFlags
+	DoneFlags = []string{"done", "shipped"}
+	t.Cleanup(func() { DoneFlags = prev })
+
+	g := buildGraph(t,
+		"c base: [task:todo] C\n\nDepends-On: b",
+		"b base: [task:shipped] B",
+		"a base: [task:qa] A",
+		"base: Base",
+	)
+
+	if got := ids(g.Pending()); got != "c,a" {
+		t.Errorf("Pending() = %s, want c,a", got)
+	}
+	if !g.IsReady("c") {
+		t.Error("c should be ready once its dependency is shipped")
+	}
+}
+
+func TestGraphCrossRepoDependency(t *testing.T) {
+	g := buildGraph(t,
+		"c b: [task:todo] C\n\nDepends-On: api:b",
+		"b base: [task:done] B",
+		"base: Base",
+	)
+	remote := &Task{ChangeID: "b", Flag: "wip", Repo: "api"}
+	g.SetCrossRepo(func(ref string) *Task {
+		if ref == "api:b" {
+			return remote
+		}
+		return nil
+	})
+
+	if got := IDs(g.UnmetDependencies("c")); len(got) != 1 || got[0] != "api:b" {
+		t.Errorf("UnmetDependencies(c) = %v, want api:b", got)
+	}
+	if g.IsReady("c") {
+		t.Error("c should not be ready while api:b is pending")
+	}
+	if got := ids(g.Dependents("b")); got != "" {
+		t.Errorf("Dependents(b) = %s, want none: the dependency is in another repo", got)
+	}
+
+	remote.Flag = "done"
+	if !g.IsReady("c") {
+		t.Error("c should be ready once api:b is done")
+	}
+}
+
+func TestGraphDependencyCycle(t *testing.T) {
+	g := buildGraph(t,
+		"c b: [task:todo] C",
+		"b base: [task:todo] B\n\nDepends-On: d",
+		"d base: [task:todo] D",
+		"base: Base",
+	)
+
+	if got := g.DependencyCycle("d", "c"); strings.Join(got, ",") != "d,c,b,d" {
+		t.Errorf("DependencyCycle(d, c) = %v, want d,c,b,d", got)
+	}
+	if got := g.DependencyCycle("b", "c"); strings.Join(got, ",") != "b,c,b" {
+		t.Errorf("depending on a descendant: %v", got)
+	}
+	if got := g.DependencyCycle("c", "d"); got != nil {
+		t.Errorf("DependencyCycle(c, d) = %v, want none", got)
+	}
+}
@@ -0,0 +1,234 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"jjtask/internal/jj"
+)
+
+// Task is a jj revision parsed from a single structured log query.
+// Non-task revisions (no [task:*] flag) are represented with an empty Flag.
+type Task struct {
+	ID          string      // full change ID
+	ChangeID    string      // shortest unique change ID prefix
+	CommitID    string      // shortest unique commit ID prefix
+	Flag        string      // task status, empty if not a task
+	Title       string      // first line without the [task:*] prefix
+	Body        string      // description after the first line, without trailers
+	Description string      // raw description
+	Trailers    Trailers    // "Key: value" lines closing the description
+	Priority    string      // Priority trailer (critical, high, medium, low)
+	Assignee    string      // Assignee trailer
+	Labels      []string    // Labels trailer, comma-separated
+	Due         string      // Due trailer (YYYY-MM-DD)
+	Estimate    string      // Estimate trailer (e.g. 2h, 1d)
+	DependsOn   []string    // Depends-On trailers (change ID prefixes)
+	Verify      []string    // Verify trailers (shell commands run by done)
+	Key         string      // Task-Key trailer set by plan apply
+	Checklist   []CheckItem // Markdown checkboxes in the description
+	Parents     []string    // parent change IDs (shortest)
+	Children    []string    // child change IDs within the loaded set
+	Empty       bool        // no changes relative to parents
+	WorkingCopy bool        // is the current working copy (@)
+	Author      string      // author name
+	Created     time.Time   // author timestamp
+	Updated     time.Time   // committer timestamp
+	Repo        string      // workspace repo, set on Depends-On tasks from another repo
+}
+
+// IsTask reports whether the revision carries a [task:*] flag
+func (t *Task) IsTask() bool {
+	return t.Flag != ""
+}
+
+// FirstLine returns the first line of the raw description
+func (t *Task) FirstLine() string {
+	return FirstLine(t.Description)
+}
+
+var flagPattern = regexp.MustCompile(`^\[task:(\w+)\]`)
+var flagPrefixPattern = regexp.MustCompile(`^\[task:\w+\]\s*`)
+
+// ParseFlag returns the task flag at the start of a description, or ""
+func ParseFlag(desc string) string {
+	if match := flagPattern.FindStringSubmatch(desc); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// ParseDescription splits a description into flag, title and body
+func ParseDescription(desc string) (flag, title, body string) {
+	first, rest, _ := strings.Cut(desc, "\n")
+	flag = ParseFlag(first)
+	title = strings.TrimSpace(flagPrefixPattern.ReplaceAllString(first, ""))
+	body = strings.TrimSpace(rest)
+	return flag, title, body
+}
+
+// FirstLine returns the first line of a description
+func FirstLine(desc string) string {
+	first, _, _ := strings.Cut(desc, "\n")
+	return first
+}
+
+// SetFlag replaces the [task:*] flag in desc, prepending one if missing
+func SetFlag(desc, flag string) string {
+	if flagPattern.MatchString(desc) {
+		return flagPattern.ReplaceAllString(desc, fmt.Sprintf("[task:%s]", flag))
+	}
+	return fmt.Sprintf("[task:%s] %s", flag, desc)
+}
+
+// StripFlag removes the [task:*] prefix and following whitespace from desc
+func StripFlag(desc string) string {
+	return flagPrefixPattern.ReplaceAllString(desc, "")
+}
+
+// Format builds a task description from its parts
+func Format(flag, title, body string) string {
+	message := fmt.Sprintf("[task:%s] %s", flag, title)
+	if body != "" {
+		message = message + "\n\n" + body
+	}
+	return message
+}
+
+// logTemplate renders one JSON object per revision.
+// Only free-form strings need escaping; IDs and timestamps are plain ASCII.
+const logTemplate = `"{" ++
+  "\"id\":\"" ++ change_id ++ "\"," ++
+  "\"change_id\":\"" ++ change_id.shortest() ++ "\"," ++
+  "\"commit_id\":\"" ++ commit_id.shortest() ++ "\"," ++
+  "\"parents\":[" ++ parents.map(|p| "\"" ++ p.change_id().shortest() ++ "\"").join(",") ++ "]," ++
+  "\"empty\":" ++ if(empty, "true", "false") ++ "," ++
+  "\"working_copy\":" ++ if(current_working_copy, "true", "false") ++ "," ++
+  "\"author\":" ++ author.name().escape_json() ++ "," ++
+  "\"created\":\"" ++ author.timestamp().format("%Y-%m-%dT%H:%M:%S%:z") ++ "\"," ++
+  "\"updated\":\"" ++ committer.timestamp().format("%Y-%m-%dT%H:%M:%S%:z") ++ "\"," ++
+  "\"description\":" ++ description.escape_json() ++
+  "}\n"`
+
+// record mirrors the JSON emitted by logTemplate
+type record struct {
+	ID          string   `json:"id"`
+	ChangeID    string   `json:"change_id"`
+	CommitID    string   `json:"commit_id"`
+	Parents     []string `json:"parents"`
+	Empty       bool     `json:"empty"`
+	WorkingCopy bool     `json:"working_copy"`
+	Author      string   `json:"author"`
+	Created     string   `json:"created"`
+	Updated     string   `json:"updated"`
+	Description string   `json:"description"`
+}
+
+// Load returns all revisions matching revset in jj log order, using one jj call
+func Load(c *jj.Client, revset string) ([]*Task, error) {
+	out, err := c.Query("log", "-r", revset, "--no-graph", "-T", logTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(out)
+}
+
+// Get returns the single revision rev resolves to
+func Get(c *jj.Client, rev string) (*Task, error) {
+	out, err := c.Query("log", "-r", rev, "--no-graph", "-T", logTemplate, "--limit", "1")
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := Parse(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("revision %q not found", rev)
+	}
+	return tasks[0], nil
+}
+
+// Parse decodes output produced by logTemplate
+func Parse(out string) ([]*Task, error) {
+	var tasks []*Task
+	byID := make(map[string]*Task)
+	for line := range strings.SplitSeq(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return nil, fmt.Errorf("parsing task record: %w", err)
+		}
+		rest, trailers := SplitTrailers(r.Description)
+		flag, title, body := ParseDescription(rest)
+		t := &Task{
+			ID:          r.ID,
+			ChangeID:    r.ChangeID,
+			CommitID:    r.CommitID,
+			Flag:        flag,
+			Title:       title,
+			Body:        body,
+			Description: r.Description,
+			Trailers:    trailers,
+			Priority:    trailers.Get(KeyPriority),
+			Assignee:    trailers.Get(KeyAssignee),
+			Labels:      ParseLabels(trailers.Get(KeyLabels)),
+			Due:         trailers.Get(KeyDue),
+			Estimate:    trailers.Get(KeyEstimate),
+			DependsOn:   trailers.All(KeyDependsOn),
+			Verify:      trailers.All(KeyVerify),
+			Key:         trailers.Get(KeyTaskKey),
+			Checklist:   ParseChecklist(r.Description),
+			Parents:     r.Parents,
+			Empty:       r.Empty,
+			WorkingCopy: r.WorkingCopy,
+			Author:      r.Author,
+			Created:     parseTime(r.Created),
+			Updated:     parseTime(r.Updated),
+		}
+		tasks = append(tasks, t)
+		byID[t.ChangeID] = t
+	}
+
+	// Children are only known within the loaded set
+	for _, t := range tasks {
+		for _, p := range t.Parents {
+			if parent, ok := byID[p]; ok {
+				parent.Children = append(parent.Children, t.ChangeID)
+			}
+		}
+	}
+	return tasks, nil
+}
+
+func parseTime(s string) time.Time {
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
+
+// Ref returns the change ID to refer to t by, qualified as "repo:id" for
+// tasks from another repo
+func (t *Task) Ref() string {
+	if t.Repo != "" {
+		return t.Repo + ":" + t.ChangeID
+	}
+	return t.ChangeID
+}
+
+// IDs returns the shortest change IDs of tasks, repo-qualified for tasks
+// from another repo
+func IDs(tasks []*Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.Ref())
+	}
+	return ids
+}
@@ -0,0 +1,78 @@
+package task
+
+import "testing"
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		flag, title, wantBody string
+	}{
+		{"[task:todo] Fix bug\n\nDetails here\n", "todo", "Fix bug", "Details here"},
+		{"[task:wip] Title only", "wip", "Title only", ""},
+		{"Regular commit\n", "", "Regular commit", ""},
+		{"Mentions [task:done] inline", "", "Mentions [task:done] inline", ""},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			flag, title, body := ParseDescription(tt.desc)
+			if flag != tt.flag || title != tt.title || body != tt.wantBody {
+				t.Errorf("ParseDescription(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.desc, flag, title, body, tt.flag, tt.title, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	tests := []struct {
+		desc, flag, want string
+	}{
+		{"[task:todo] Fix bug\n", "wip", "[task:wip] Fix bug\n"},
+		{"Fix bug\n", "todo", "[task:todo] Fix bug\n"},
+		{"[task:wip] A\n\nmentions [task:wip]\n", "done", "[task:done] A\n\nmentions [task:wip]\n"},
+	}
+
+	for _, tt := range tests {
+		if got := SetFlag(tt.desc, tt.flag); got != tt.want {
+			t.Errorf("SetFlag(%q, %q) = %q, want %q", tt.desc, tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestStripFlag(t *testing.T) {
+	if got := StripFlag("[task:done] Title\n\nBody\n"); got != "Title\n\nBody\n" {
+		t.Errorf("StripFlag = %q", got)
+	}
+	if got := StripFlag("Title\n"); got != "Title\n" {
+		t.Errorf("StripFlag without flag = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	out := `{"id":"kkmpptxzrspxrzommnulwmwkkqwworpl","change_id":"k","commit_id":"1a","parents":["q"],"empty":true,"working_copy":false,"author":"Test User","created":"2001-02-03T04:05:09+07:00","updated":"2001-02-03T04:05:09+07:00","description":"[task:todo] Child\n\nSpec \"quoted\"\n"}
+{"id":"qpvuntsmwlqtpsluzzsnyyzlmlwvmlnu","change_id":"q","commit_id":"2b","parents":["z"],"empty":false,"working_copy":true,"author":"Test User","created":"2001-02-03T04:05:07+07:00","updated":"2001-02-03T04:05:08+07:00","description":""}
+`
+	tasks, err := Parse(out)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+
+	child, parent := tasks[0], tasks[1]
+	if child.Flag != "todo" || child.Title != "Child" || child.Body != `Spec "quoted"` {
+		t.Errorf("child parsed as flag=%q title=%q body=%q", child.Flag, child.Title, child.Body)
+	}
+	if !child.Empty || child.WorkingCopy || child.Created.IsZero() {
+		t.Errorf("child fields: empty=%v wc=%v created=%v", child.Empty, child.WorkingCopy, child.Created)
+	}
+	if parent.IsTask() || !parent.WorkingCopy {
+		t.Errorf("parent should be a non-task working copy")
+	}
+	if len(parent.Children) != 1 || parent.Children[0] != "k" {
+		t.Errorf("parent children = %v, want [k]", parent.Children)
+	}
+}
@@ -0,0 +1,233 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Trailer keys for task metadata
+const (
+	KeyPriority  = "Priority"
+	KeyAssignee  = "Assignee"
+	KeyLabels    = "Labels"
+	KeyDue       = "Due"
+	KeyEstimate  = "Estimate"
+	KeyDependsOn = "Depends-On"
+	KeyVerify    = "Verify"
+	KeyTaskKey   = "Task-Key"
+)
+
+// KnownKeys lists the trailer keys jjtask understands. A final paragraph of
+// "Key: value" lines is only treated as trailers if it uses one of them, so
+// ordinary prose like "Note: ..." at the end of a spec stays in the body.
+// jjtask adds the custom keys from [metadata] keys in .jjtask.toml.
+var KnownKeys = []string{KeyPriority, KeyAssignee, KeyLabels, KeyDue, KeyEstimate, KeyDependsOn, KeyVerify, KeyTaskKey}
+
+// Priorities in descending order of urgency
+var Priorities = []string{"critical", "high", "medium", "low"}
+
+// DueLayout is the date format for the Due trailer
+const DueLayout = "2006-01-02"
+
+// Trailer is a single "Key: value" line at the end of a description
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Trailers is an ordered list of description trailers
+type Trailers []Trailer
+
+var trailerPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*):\s*(.*)$`)
+var estimatePattern = regexp.MustCompile(`^\d+(\.\d+)?[mhdw]$`)
+
+// Get returns the value of the first trailer with key, or ""
+func (ts Trailers) Get(key string) string {
+	key = CanonicalKey(key)
+	for _, t := range ts {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// All returns the values of every trailer with key
+func (ts Trailers) All(key string) []string {
+	key = CanonicalKey(key)
+	var values []string
+	for _, t := range ts {
+		if t.Key == key {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// CanonicalKey normalizes a trailer key: "due", "DUE" -> "Due",
+// "depends-on" -> "Depends-On"
+func CanonicalKey(key string) string {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(key)), "-")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// SplitTrailers separates the trailer block from the rest of desc. The
+// trailer block is the last paragraph, after the title, when every line in
+// it is "Key: value" and at least one key is known.
+func SplitTrailers(desc string) (rest string, trailers Trailers) {
+	trimmed := strings.TrimRight(desc, "\n")
+	idx := strings.LastIndex(trimmed, "\n\n")
+	if idx < 0 {
+		return desc, nil
+	}
+
+	known := false
+	for line := range strings.SplitSeq(trimmed[idx+2:], "\n") {
+		m := trailerPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			return desc, nil
+		}
+		key := CanonicalKey(m[1])
+		known = known || slices.Contains(KnownKeys, key)
+		trailers = append(trailers, Trailer{Key: key, Value: strings.TrimSpace(m[2])})
+	}
+	if !known {
+		return desc, nil
+	}
+	return trimmed[:idx] + "\n", trailers
+}
+
+// JoinTrailers appends trailers to rest as the final paragraph
+func JoinTrailers(rest string, trailers Trailers) string {
+	rest = strings.TrimRight(rest, "\n")
+	if len(trailers) == 0 {
+		return rest + "\n"
+	}
+	lines := make([]string, len(trailers))
+	for i, t := range trailers {
+		lines[i] = t.Key + ": " + t.Value
+	}
+	block := strings.Join(lines, "\n") + "\n"
+	if rest == "" {
+		return block
+	}
+	return rest + "\n\n" + block
+}
+
+// SetTrailer sets key to value in desc, replacing existing occurrences
+func SetTrailer(desc, key, value string) string {
+	rest, trailers := SplitTrailers(desc)
+	key = CanonicalKey(key)
+	var updated Trailers
+	replaced := false
+	for _, t := range trailers {
+		if t.Key != key {
+			updated = append(updated, t)
+		} else if !replaced {
+			updated = append(updated, Trailer{Key: key, Value: value})
+			replaced = true
+		}
+	}
+	if !replaced {
+		updated = append(updated, Trailer{Key: key, Value: value})
+	}
+	return JoinTrailers(rest, updated)
+}
+
+// AddTrailer appends a key: value trailer unless the exact pair exists
+func AddTrailer(desc, key, value string) string {
+	rest, trailers := SplitTrailers(desc)
+	key = CanonicalKey(key)
+	if slices.Contains(trailers, Trailer{Key: key, Value: value}) {
+		return desc
+	}
+	return JoinTrailers(rest, append(trailers, Trailer{Key: key, Value: value}))
+}
+
+// UnsetTrailer removes every trailer with key from desc. With values, only
+// trailers with one of those values are removed.
+func UnsetTrailer(desc, key string, values ...string) string {
+	rest, trailers := SplitTrailers(desc)
+	key = CanonicalKey(key)
+	var kept Trailers
+	for _, t := range trailers {
+		if t.Key == key && (len(values) == 0 || slices.Contains(values, t.Value)) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if len(kept) == len(trailers) {
+		return desc
+	}
+	return JoinTrailers(rest, kept)
+}
+
+// NormalizeTrailer validates value for key and returns its canonical form
+func NormalizeTrailer(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch CanonicalKey(key) {
+	case KeyPriority:
+		p := strings.ToLower(value)
+		if !slices.Contains(Priorities, p) {
+			return "", fmt.Errorf("invalid priority %q, must be one of: %s", value, strings.Join(Priorities, ", "))
+		}
+		return p, nil
+	case KeyLabels:
+		labels := ParseLabels(value)
+		if len(labels) == 0 {
+			return "", fmt.Errorf("no labels given")
+		}
+		return strings.Join(labels, ","), nil
+	case KeyDue:
+		if _, err := time.Parse(DueLayout, value); err != nil {
+			return "", fmt.Errorf("invalid due date %q, expected YYYY-MM-DD", value)
+		}
+		return value, nil
+	case KeyEstimate:
+		if !estimatePattern.MatchString(value) {
+			return "", fmt.Errorf("invalid estimate %q, expected a number with m, h, d or w (e.g. 30m, 2h, 1.5d)", value)
+		}
+		return value, nil
+	}
+	if value == "" {
+		return "", fmt.Errorf("empty value for %s", CanonicalKey(key))
+	}
+	if strings.Contains(value, "\n") {
+		return "", fmt.Errorf("trailer values must be a single line")
+	}
+	return value, nil
+}
+
+// ParseLabels splits a comma-separated label list, dropping blanks and duplicates
+func ParseLabels(value string) []string {
+	var labels []string
+	for l := range strings.SplitSeq(value, ",") {
+		l = strings.TrimSpace(l)
+		if l != "" && !slices.Contains(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+// PriorityRank orders priorities from most (0) to least urgent. Tasks
+// without a priority rank as medium.
+func PriorityRank(priority string) int {
+	if i := slices.Index(Priorities, priority); i >= 0 {
+		return i
+	}
+	return slices.Index(Priorities, "medium")
+}
+
+// HasLabel reports whether the task carries label
+func (t *Task) HasLabel(label string) bool {
+	return slices.Contains(t.Labels, label)
+}
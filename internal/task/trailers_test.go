@@ -0,0 +1,110 @@
+package task
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitTrailers(t *testing.T) {
+	tests := []struct {
+		desc     string
+		rest     string
+		trailers Trailers
+	}{
+		{
+			"[task:todo] A\n\nSpec\n\nPriority: high\nlabels: db, api\n",
+			"[task:todo] A\n\nSpec\n",
+			Trailers{{"Priority", "high"}, {"Labels", "db, api"}},
+		},
+		{"[task:todo] A\n\nAssignee: alice\n", "[task:todo] A\n", Trailers{{"Assignee", "alice"}}},
+		// Unknown keys alone are prose, not trailers
+		{"[task:todo] A\n\nNote: remember this\n", "[task:todo] A\n\nNote: remember this\n", nil},
+		// Mixed paragraphs are not trailers
+		{"[task:todo] A\n\nPriority: high\nsome text\n", "[task:todo] A\n\nPriority: high\nsome text\n", nil},
+		{"[task:todo] Priority: high\n", "[task:todo] Priority: high\n", nil},
+	}
+
+	for _, tt := range tests {
+		rest, trailers := SplitTrailers(tt.desc)
+		if rest != tt.rest || len(trailers) != len(tt.trailers) {
+			t.Errorf("SplitTrailers(%q) = %q, %v", tt.desc, rest, trailers)
+			continue
+		}
+		for i := range trailers {
+			if trailers[i] != tt.trailers[i] {
+				t.Errorf("SplitTrailers(%q) trailer %d = %v, want %v", tt.desc, i, trailers[i], tt.trailers[i])
+			}
+		}
+	}
+}
+
+func TestSetAndUnsetTrailer(t *testing.T) {
+	desc := "[task:todo] A\n\nSpec\n"
+
+	desc = SetTrailer(desc, "priority", "high")
+	desc = SetTrailer(desc, "Assignee", "alice")
+	desc = SetTrailer(desc, "Priority", "low")
+	if want := "[task:todo] A\n\nSpec\n\nPriority: low\nAssignee: alice\n"; desc != want {
+		t.Fatalf("after set: %q, want %q", desc, want)
+	}
+
+	desc = UnsetTrailer(desc, "priority")
+	if want := "[task:todo] A\n\nSpec\n\nAssignee: alice\n"; desc != want {
+		t.Fatalf("after unset: %q, want %q", desc, want)
+	}
+
+	desc = UnsetTrailer(desc, "assignee")
+	if want := "[task:todo] A\n\nSpec\n"; desc != want {
+		t.Fatalf("after unsetting last trailer: %q, want %q", desc, want)
+	}
+}
+
+func TestCustomTrailerRoundTrip(t *testing.T) {
+	prev := KnownKeys
+	KnownKeys = append(slices.Clone(KnownKeys), "Reviewer")
+	t.Cleanup(func() { KnownKeys = prev })
+
+	desc := SetTrailer("[task:todo] A\n\nSpec\n", "Reviewer", "bob")
+	desc = SetTrailer(desc, "reviewer", "alice")
+	if want := "[task:todo] A\n\nSpec\n\nReviewer: alice\n"; desc != want {
+		t.Fatalf("after set: %q, want %q", desc, want)
+	}
+	if _, trailers := SplitTrailers(desc); trailers.Get("Reviewer") != "alice" {
+		t.Errorf("trailers = %v, want Reviewer: alice", trailers)
+	}
+}
+
+func TestNormalizeTrailer(t *testing.T) {
+	tests := []struct {
+		key, value, want string
+		wantErr          bool
+	}{
+		{"priority", "HIGH", "high", false},
+		{"priority", "urgent", "", true},
+		{"labels", " db, api ,db,", "db,api", false},
+		{"due", "2026-11-01", "2026-11-01", false},
+		{"due", "next week", "", true},
+		{"estimate", "1.5d", "1.5d", false},
+		{"estimate", "soon", "", true},
+		{"assignee", "agent-1", "agent-1", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTrailer(tt.key, tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeTrailer(%q, %q) = %q, %v", tt.key, tt.value, got, err)
+		}
+	}
+}
+
+func TestParseTaskMetadata(t *testing.T) {
+	out := `{"id":"k","change_id":"k","parents":[],"description":"[task:todo] A\n\nSpec\n\nPriority: high\nLabels: db,api\nDue: 2026-11-01\n"}`
+	tasks, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tk := tasks[0]
+	if tk.Priority != "high" || tk.Due != "2026-11-01" || !tk.HasLabel("api") || tk.Body != "Spec" {
+		t.Errorf("parsed priority=%q due=%q labels=%v body=%q", tk.Priority, tk.Due, tk.Labels, tk.Body)
+	}
+}
@@ -58,6 +58,22 @@ func DisplayName(repo Repo) string {
 	return repo.Path
 }
 
+// SplitRef splits a repo-qualified revision like "api:xqq" into the repo it
+// names and the revision. ok is false when ref has no known repo prefix, so
+// revsets such as "a::b" and titles such as "api: fix" are left alone.
+func SplitRef(ref string, repos []Repo) (repo Repo, rev string, ok bool) {
+	name, rev, found := strings.Cut(ref, ":")
+	if !found || rev == "" || strings.HasPrefix(rev, ":") || strings.ContainsAny(rev, " \t\n") {
+		return Repo{}, ref, false
+	}
+	for _, r := range repos {
+		if DisplayName(r) == name {
+			return r, rev, true
+		}
+	}
+	return Repo{}, ref, false
+}
+
 // RelativePath computes relative path from cwd to target
 func RelativePath(target string) string {
 	cwd, err := os.Getwd()